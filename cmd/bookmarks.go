@@ -0,0 +1,224 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/Nexusrex18/medCli/internal/bookmarks"
+	"github.com/Nexusrex18/medCli/internal/client"
+	"github.com/Nexusrex18/medCli/internal/models"
+	"github.com/Nexusrex18/medCli/internal/repository"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// openBookmarks shows the bookmarks screen listing every bookmark
+func (m *model) openBookmarks() tea.Cmd {
+	m.state = StateBookmarks
+	m.input.Reset()
+	m.input.Placeholder = "Filter bookmarks by code, title or type..."
+	m.input.Focus()
+	m.currentRecords = nil
+	m.selectedIndex = 0
+	m.groupedView = false
+	return m.filterBookmarks()
+}
+
+// filterBookmarks lists the bookmarks matching the input from the first
+// page. The list is filtered as the input is typed.
+func (m *model) filterBookmarks() tea.Cmd {
+	m.lastSearchType = "bookmarks"
+	m.lastQuery = m.input.Value()
+	m.pageOffset = 0
+	return m.startSearch()
+}
+
+// refreshBookmarks lists the bookmarks again after one was removed,
+// keeping the selection where it was
+func (m *model) refreshBookmarks() tea.Cmd {
+	remaining := len(m.bookmarks.Search(m.lastQuery))
+	index := max(min(m.pageOffset+m.selectedIndex, remaining-1), 0)
+	m.pageOffset = index - index%m.pageSize()
+	cmd := m.startSearch()
+	m.selectOnLoad = index - m.pageOffset
+	return cmd
+}
+
+// updateBookmarks handles the bookmarks screen, which works like the
+// search screens but filters as the input changes. Keys that type text
+// always go to the filter, so only the other list keys move the selection.
+func (m model) updateBookmarks(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if m.jumping {
+		return m.updateJump(msg)
+	}
+	if msg, ok := msg.(tea.KeyMsg); ok && !typesText(msg) {
+		if cmd, ok := m.updateList(msg); ok {
+			return m, cmd
+		}
+		switch {
+		case key.Matches(msg, m.keys.Search.Cancel):
+			if m.input.Value() != "" {
+				m.input.Reset()
+				return m, m.filterBookmarks()
+			}
+			m.closeBookmarks()
+			return m, nil
+		case key.Matches(msg, m.keys.Search.Back):
+			m.closeBookmarks()
+			return m, nil
+		case key.Matches(msg, m.keys.Bookmarks.Remove):
+			if m.listing() && m.bookmarked(m.currentRecords[m.selectedIndex]) {
+				return m, m.toggleBookmark(m.currentRecords[m.selectedIndex])
+			}
+			return m, nil
+		case key.Matches(msg, m.keys.Search.Submit):
+			if m.listing() {
+				m.openDetail()
+				m.resultsState = m.state
+				m.state = StatePopup
+			}
+			return m, nil
+		}
+	}
+
+	before := m.input.Value()
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	if m.input.Value() != before {
+		return m, tea.Batch(cmd, m.filterBookmarks())
+	}
+	return m, cmd
+}
+
+// closeBookmarks goes back to the menu
+func (m *model) closeBookmarks() {
+	m.cancelSearch()
+	m.state = StateMenu
+	m.currentRecords = nil
+	m.selectedIndex = 0
+	m.viewingResults = false
+	m.results = ""
+}
+
+func (m model) viewBookmarks() string {
+	inputDisplay := inputFocusedStyle.Copy().Width(m.inputWidth()).Render(m.input.View())
+	return m.place(m.join(lipgloss.Left,
+		m.title("⭐ Bookmarks"),
+		"",
+		inputDisplay,
+		"",
+		m.resultsBox(),
+		"",
+		m.statusLine(),
+	))
+}
+
+// toggleBookmark bookmarks record, or removes its bookmark. Removing one
+// on the bookmarks screen takes it off the list.
+func (m *model) toggleBookmark(record models.MedicineRecord) tea.Cmd {
+	if m.bookmarks == nil {
+		return nil
+	}
+	starred, err := m.bookmarks.Toggle(record)
+	m.bookmarkErr = err
+	if err == nil && !starred && m.state == StateBookmarks && m.lastSearchType == "bookmarks" {
+		return m.refreshBookmarks()
+	}
+	return nil
+}
+
+// bookmarked reports whether record is bookmarked
+func (m model) bookmarked(record models.MedicineRecord) bool {
+	return m.bookmarks != nil && m.bookmarks.Has(record.TM2Code, record.Code)
+}
+
+// starAction describes what the star key does to the selected record
+func (m model) starAction() string {
+	if m.selectedIndex < len(m.currentRecords) && m.bookmarked(m.currentRecords[m.selectedIndex]) {
+		return "remove bookmark"
+	}
+	return "bookmark"
+}
+
+// starLabel is appended to the position of the selected record: whether it
+// is bookmarked, or why the last change to the bookmarks failed
+func (m model) starLabel(record models.MedicineRecord) string {
+	switch {
+	case m.bookmarkErr != nil:
+		return " • ⚠️  " + m.bookmarkErr.Error()
+	case m.bookmarked(record):
+		return " • ★ Bookmarked"
+	}
+	return ""
+}
+
+// bookmarkedRecords returns one page of marks as records. Bookmarks whose
+// mapping is missing from the loaded data are shown from what the
+// bookmark kept of it.
+func bookmarkedRecords(tm2Client *client.TM2Client, marks []bookmarks.Bookmark, opts repository.SearchOptions) ([]models.MedicineRecord, int) {
+	start := min(opts.Offset, len(marks))
+	end := len(marks)
+	if opts.Limit > 0 {
+		end = min(start+opts.Limit, end)
+	}
+
+	records := make([]models.MedicineRecord, 0, end-start)
+	for _, mark := range marks[start:end] {
+		record, ok := tm2Client.FindRecord(mark.TM2Code, mark.Code)
+		if !ok {
+			record = mark.Record()
+		}
+		records = append(records, record)
+	}
+	return records, len(marks)
+}
+
+// noBookmarks explains an empty bookmarks list
+func (m model) noBookmarks() string {
+	if m.lastQuery != "" {
+		return fmt.Sprintf("No bookmark matches %q", m.lastQuery)
+	}
+	return fmt.Sprintf("Press %s on a search result or in its details to bookmark it", m.keys.List.Star.Help().Key)
+}
+
+// formatBookmarkResults renders a page of bookmarked records, or hint
+// when there are none
+func formatBookmarkResults(records []models.MedicineRecord, selectedIndex, offset, total, width int, hint string) resultPage {
+	if len(records) == 0 {
+		empty := resultBoxStyle.Copy().
+			BorderForeground(mutedTextColor).
+			Width(width - 2).
+			Height(6).
+			Align(lipgloss.Center).
+			Render(
+				lipgloss.JoinVertical(lipgloss.Center,
+					"⭐ No bookmarks",
+					resultMutedStyle.Render(hint),
+				),
+			)
+		return resultPage{header: empty}
+	}
+
+	page := resultPage{header: lipgloss.JoinVertical(lipgloss.Left,
+		resultTitleStyle.Render("⭐ "+pageHeader("bookmarks", len(records), offset, total)),
+		"",
+	)}
+
+	for i, record := range records {
+		titleStyle := resultTitleStyle
+		subtitleStyle := resultSubtitleStyle
+		textStyle := resultTextStyle
+		if i == selectedIndex {
+			titleStyle = selected(titleStyle).Bold(true)
+			subtitleStyle = selected(subtitleStyle)
+			textStyle = selected(textStyle)
+		}
+
+		page.items = append(page.items, lipgloss.JoinVertical(lipgloss.Left,
+			titleStyle.Render(fmt.Sprintf("%d. %s", offset+i+1, record.TM2Title)),
+			subtitleStyle.Render(fmt.Sprintf("   🏷️  TM2 Code: %s • Traditional: %s", record.TM2Code, record.Code)),
+			textStyle.Render(fmt.Sprintf("   📁 %s • %s", record.Type, record.CodeTitle)),
+		))
+	}
+	return page
+}
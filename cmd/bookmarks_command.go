@@ -0,0 +1,150 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Nexusrex18/medCli/internal/bookmarks"
+)
+
+// bookmarkActions are the verbs of `medCli bookmarks`
+var bookmarkActions = map[string]func(args []string) error{
+	"list":   runBookmarksList,
+	"remove": runBookmarksRemove,
+	"export": runBookmarksExport,
+	"import": runBookmarksImport,
+}
+
+func runBookmarks(args []string) error {
+	if len(args) == 0 {
+		return runBookmarksList(nil)
+	}
+	action, ok := bookmarkActions[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown bookmarks action %q (want list, remove, export or import)", args[0])
+	}
+	return action(args[1:])
+}
+
+func runBookmarksList(args []string) error {
+	fs := flag.NewFlagSet("bookmarks list", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "print the bookmarks as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	store, err := bookmarks.Open(bookmarks.Path())
+	if err != nil {
+		return err
+	}
+	found := store.Search(strings.Join(fs.Args(), " "))
+
+	if *asJSON {
+		if found == nil {
+			found = []bookmarks.Bookmark{}
+		}
+		return printJSON(found)
+	}
+	if len(found) == 0 {
+		fmt.Println("No bookmarks found")
+		return nil
+	}
+	for _, b := range found {
+		fmt.Printf("%-10s %-12s %s\n", b.TM2Code, b.Code, b.TM2Title)
+		var details []string
+		for _, detail := range []string{b.CodeTitle, b.Type} {
+			if detail != "" {
+				details = append(details, detail)
+			}
+		}
+		if len(details) > 0 {
+			fmt.Printf("%-23s %s\n", "", strings.Join(details, " • "))
+		}
+	}
+	return nil
+}
+
+func runBookmarksRemove(args []string) error {
+	if len(args) != 2 {
+		return errors.New("usage: medCli bookmarks remove <tm2-code> <code>")
+	}
+
+	store, err := bookmarks.Open(bookmarks.Path())
+	if err != nil {
+		return err
+	}
+	removed, err := store.Remove(args[0], args[1])
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return fmt.Errorf("%s / %s is not bookmarked", args[0], args[1])
+	}
+	fmt.Printf("Removed %s / %s\n", args[0], args[1])
+	return nil
+}
+
+// runBookmarksExport writes the bookmarks to a file, or to stdout when no
+// file is given
+func runBookmarksExport(args []string) error {
+	if len(args) > 1 {
+		return errors.New("usage: medCli bookmarks export [file]")
+	}
+
+	store, err := bookmarks.Open(bookmarks.Path())
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 || args[0] == "-" {
+		return store.Export(os.Stdout)
+	}
+
+	f, err := os.Create(args[0])
+	if err != nil {
+		return err
+	}
+	if err := store.Export(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Printf("Exported %d bookmarks to %s\n", len(store.List()), args[0])
+	return nil
+}
+
+// runBookmarksImport adds the bookmarks of an export, read from a file or
+// from stdin when the file is "-", to the user's own
+func runBookmarksImport(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: medCli bookmarks import <file|->")
+	}
+
+	store, err := bookmarks.Open(bookmarks.Path())
+	if err != nil {
+		return err
+	}
+
+	var r io.Reader = os.Stdin
+	if args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	before := len(store.List())
+	added, err := store.Import(r)
+	if err != nil {
+		return fmt.Errorf("failed to import %s: %w", args[0], err)
+	}
+	fmt.Printf("Imported %d new bookmarks (%d total) into %s\n", added, before+added, store.Path())
+	return nil
+}
@@ -0,0 +1,407 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/Nexusrex18/medCli/internal/client"
+	"github.com/Nexusrex18/medCli/internal/config"
+	"github.com/Nexusrex18/medCli/internal/models"
+	"github.com/Nexusrex18/medCli/internal/query"
+	"github.com/Nexusrex18/medCli/internal/repository"
+)
+
+// command is a non-interactive subcommand. Running medCli without
+// arguments starts the TUI instead.
+type command struct {
+	usage   string
+	summary string
+	run     func(args []string) error
+}
+
+var commands = map[string]command{
+	"symptoms": {
+		usage:   "symptoms [flags] <symptom>[, <symptom>...]",
+		summary: "Find records matching every symptom, with match highlights",
+		run:     runSymptoms,
+	},
+	"similar": {
+		usage:   "similar [flags] <code>",
+		summary: "List the TM2 concepts most similar to a TM2 or traditional code",
+		run:     runSimilar,
+	},
+	"bookmarks": {
+		usage:   "bookmarks [list|remove|export|import]",
+		summary: "List, remove, export or import the mappings starred in the TUI",
+		run:     runBookmarks,
+	},
+	"config": {
+		usage:   "config path|show|get|set|validate|init",
+		summary: "Inspect, change and validate the configuration",
+		run:     runConfig,
+	},
+	"stats": {
+		usage:   "stats [--metrics] [--json]",
+		summary: "Show dataset and cache statistics, or the load metrics in Prometheus format",
+		run:     runStats,
+	},
+	"history": {
+		usage:   "history [list|clear]",
+		summary: "List or clear the searches made in the TUI",
+		run:     runHistory,
+	},
+	"query": {
+		usage:   "query [flags] <expression>",
+		summary: `Search with the query language, e.g. 'type:Unani (fever OR humma)'`,
+		run:     runQuery,
+	},
+}
+
+// runCommand dispatches args to a subcommand and returns the exit code
+func runCommand(args []string) int {
+	name := args[0]
+	if name == "help" || name == "-h" || name == "--help" {
+		printUsage()
+		return 0
+	}
+
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", name)
+		printUsage()
+		return 2
+	}
+
+	if err := cmd.run(args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// configOptions holds the global flags that shape configuration loading
+var configOptions config.Options
+
+// parseGlobalFlags reads the flags given before the command and returns
+// the remaining arguments
+func parseGlobalFlags(args []string) ([]string, error) {
+	fs := flag.NewFlagSet("medCli", flag.ContinueOnError)
+	fs.StringVar(&configOptions.DataPath, "data", "", "CSV data file (overrides "+config.DataEnv+" and the config file)")
+	fs.StringVar(&metricsAddr, "metrics-addr", "", "serve Prometheus metrics at /metrics on this address while the TUI runs")
+	fs.Usage = printUsage
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	return fs.Args(), nil
+}
+
+func printUsage() {
+	fmt.Fprintln(os.Stderr, "Usage: medCli [--data file.csv] [--metrics-addr host:port] [command]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Run without a command to start the interactive TUI.")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Global flags:")
+	fmt.Fprintf(os.Stderr, "  %-32s %s\n", "--data file.csv", "CSV data file, overriding "+config.DataEnv+" and csv.file_path")
+	fmt.Fprintf(os.Stderr, "  %-32s %s\n", "--metrics-addr host:port", "Serve Prometheus metrics at /metrics while the TUI runs")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-32s %s\n", commands[name].usage, commands[name].summary)
+	}
+}
+
+// newCommandClient loads the configuration and data for a subcommand
+func newCommandClient() (*client.TM2Client, *config.Loaded, error) {
+	cfg, err := config.Load(configOptions)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	// A missing data file is reported as config validate reports it
+	if errs, ok := config.Validate(cfg.Config).(config.ValidationErrors); ok {
+		if fieldErr, found := errs.For("csv.file_path"); found {
+			return nil, nil, fieldErr
+		}
+	}
+
+	tm2Client, err := client.NewTM2Client(cfg.Config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return tm2Client, cfg, nil
+}
+
+// pagingFlags holds the paging and output flags shared by search commands
+type pagingFlags struct {
+	offset     int
+	limit      int
+	sortBy     string
+	descending bool
+	json       bool
+}
+
+func (p *pagingFlags) register(fs *flag.FlagSet) {
+	fs.IntVar(&p.offset, "offset", 0, "number of results to skip")
+	fs.IntVar(&p.limit, "limit", 20, "maximum number of results (0 for all)")
+	fs.StringVar(&p.sortBy, "sort", "confidence", "sort key: confidence, code or title")
+	fs.BoolVar(&p.descending, "desc", true, "sort in descending order")
+	fs.BoolVar(&p.json, "json", false, "print results as JSON")
+}
+
+func (p *pagingFlags) options() (repository.SearchOptions, error) {
+	sortBy, err := repository.ParseSortKey(p.sortBy)
+	if err != nil {
+		return repository.SearchOptions{}, err
+	}
+	return repository.SearchOptions{
+		Offset:     p.offset,
+		Limit:      p.limit,
+		SortBy:     sortBy,
+		Descending: p.descending,
+	}, nil
+}
+
+func runQuery(args []string) error {
+	fs := flag.NewFlagSet("query", flag.ContinueOnError)
+	var paging pagingFlags
+	paging.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	input := strings.Join(fs.Args(), " ")
+	if input == "" {
+		return errors.New("usage: medCli query [flags] <expression>")
+	}
+
+	opts, err := paging.options()
+	if err != nil {
+		return err
+	}
+
+	tm2Client, _, err := newCommandClient()
+	if err != nil {
+		return err
+	}
+
+	result, err := tm2Client.SearchByQuery(context.Background(), input, opts)
+	if err != nil {
+		var syntaxErr *query.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			return fmt.Errorf("%w\n\n  %s", err, strings.ReplaceAll(syntaxErr.Caret(), "\n", "\n  "))
+		}
+		return err
+	}
+
+	if paging.json {
+		return printJSON(result)
+	}
+	printRecords(result.Records, result.Offset, result.Total)
+	return nil
+}
+
+func runSymptoms(args []string) error {
+	fs := flag.NewFlagSet("symptoms", flag.ContinueOnError)
+	var paging pagingFlags
+	paging.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	input := strings.Join(fs.Args(), " ")
+	if strings.TrimSpace(input) == "" {
+		return errors.New("usage: medCli symptoms [flags] <symptom>[, <symptom>...]")
+	}
+	symptoms := strings.Split(input, ",")
+	for i := range symptoms {
+		symptoms[i] = strings.TrimSpace(symptoms[i])
+	}
+
+	opts, err := paging.options()
+	if err != nil {
+		return err
+	}
+
+	tm2Client, _, err := newCommandClient()
+	if err != nil {
+		return err
+	}
+
+	result, err := tm2Client.SearchBySymptoms(context.Background(), symptoms, opts)
+	if err != nil {
+		return err
+	}
+
+	if paging.json {
+		return printJSON(result)
+	}
+	printSymptomMatches(result)
+	return nil
+}
+
+func runSimilar(args []string) error {
+	fs := flag.NewFlagSet("similar", flag.ContinueOnError)
+	offset := fs.Int("offset", 0, "number of results to skip")
+	limit := fs.Int("limit", 10, "maximum number of results (0 for all)")
+	asJSON := fs.Bool("json", false, "print results as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("usage: medCli similar [flags] <code>")
+	}
+
+	tm2Client, _, err := newCommandClient()
+	if err != nil {
+		return err
+	}
+
+	opts := repository.SearchOptions{Offset: *offset, Limit: *limit}
+	result, err := tm2Client.SimilarByCode(context.Background(), fs.Arg(0), opts)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		return printJSON(result)
+	}
+
+	fmt.Printf("Similar to %s %s (%s)\n", result.Source.TM2Code, result.Source.TM2Title, result.Source.Code)
+	if result.Total == 0 {
+		fmt.Println("No similar records found")
+		return nil
+	}
+	fmt.Println(pageHeader("similar concepts", len(result.Records), result.Offset, result.Total))
+	for i, scored := range result.Records {
+		record := scored.Record
+		fmt.Printf("\n%d. %s\n", result.Offset+i+1, record.TM2Title)
+		fmt.Printf("   TM2 Code: %s • Traditional: %s (%s)\n", record.TM2Code, record.Code, record.CodeTitle)
+		fmt.Printf("   Similarity: %.1f%% • Confidence: %.1f%%\n", scored.Score*100, record.ConfidenceScore*100)
+	}
+	return nil
+}
+
+func runStats(args []string) error {
+	fs := flag.NewFlagSet("stats", flag.ContinueOnError)
+	asMetrics := fs.Bool("metrics", false, "print the metrics of loading the data in the Prometheus text format")
+	asJSON := fs.Bool("json", false, "print statistics as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return errors.New("usage: medCli stats [--metrics] [--json]")
+	}
+
+	tm2Client, cfg, err := newCommandClient()
+	if err != nil {
+		return err
+	}
+
+	// The data was loaded for this command alone, so only the dataset
+	// metrics say anything. Searches are counted by a TUI started with
+	// --metrics-addr.
+	if *asMetrics {
+		return tm2Client.Metrics().WriteText(os.Stdout)
+	}
+
+	stats := tm2Client.GetRepoStats()
+	cacheStats := tm2Client.GetCacheStats()
+	if *asJSON {
+		return printJSON(map[string]any{
+			"data_file": cfg.Config.CSV.FilePath,
+			"data_rule": cfg.DataRule,
+			"dataset":   stats,
+			"cache":     cacheStats,
+		})
+	}
+
+	fmt.Printf("Data file: %s (%s)\n", cfg.Config.CSV.FilePath, cfg.DataRule)
+	fmt.Printf("Records: %d • Unique codes: %d • Unique TM2 codes: %d\n",
+		stats["total_records"], stats["unique_codes"], stats["unique_tm2_codes"])
+	fmt.Printf("Cardinality: one-to-one %d • one-to-many %d • many-to-one %d • many-to-many %d\n",
+		stats[string(models.OneToOne)], stats[string(models.OneToMany)], stats[string(models.ManyToOne)], stats[string(models.ManyToMany)])
+	if !cacheStats.Enabled {
+		fmt.Println("Cache: disabled")
+		return nil
+	}
+	limit := "unbounded"
+	if cacheStats.MaxItems > 0 {
+		limit = fmt.Sprintf("max %d items", cacheStats.MaxItems)
+	}
+	fmt.Printf("Cache: %s, TTL %s • hits %d • misses %d • evictions %d\n",
+		limit, cfg.Config.Cache.TTL, cacheStats.Hits, cacheStats.Misses, cacheStats.Evictions)
+	return nil
+}
+
+func printJSON(v any) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// printRecords writes a plain-text listing of one page of records
+func printRecords(records []models.MedicineRecord, offset, total int) {
+	if total == 0 {
+		fmt.Println("No results found")
+		return
+	}
+
+	fmt.Println(pageHeader("results", len(records), offset, total))
+	for i, record := range records {
+		fmt.Printf("\n%d. %s\n", offset+i+1, record.TM2Title)
+		fmt.Printf("   TM2 Code: %s • Traditional: %s (%s)\n", record.TM2Code, record.Code, record.CodeTitle)
+		fmt.Printf("   Type: %s • Confidence: %.1f%%\n", record.Type, record.ConfidenceScore*100)
+	}
+}
+
+// printSymptomMatches lists one page of symptom matches like printRecords,
+// with the matched spans in brackets and the synonyms terms matched through
+func printSymptomMatches(result *client.SymptomSearchResult) {
+	if result.Total == 0 {
+		fmt.Println("No results found")
+		return
+	}
+
+	bracket := func(matched string) string { return "[" + matched + "]" }
+	plain := func(text string) string { return text }
+
+	fmt.Println(pageHeader("results", len(result.Records), result.Offset, result.Total))
+	for i, record := range result.Records {
+		var match models.SymptomMatch
+		if i < len(result.Matches) {
+			match = result.Matches[i]
+		}
+
+		fmt.Printf("\n%d. %s\n", result.Offset+i+1, markSpans(record.TM2Title, match.HighlightsFor("tm2_title"), plain, bracket))
+		fmt.Printf("   TM2 Code: %s • Traditional: %s (%s)\n", record.TM2Code, record.Code, record.CodeTitle)
+		fmt.Printf("   Type: %s • Confidence: %.1f%%\n", record.Type, record.ConfidenceScore*100)
+		if ex, ok := matchExcerpt(record, match.Highlights, 60); ok {
+			snippet := markSpans(ex.text, ex.highlights, plain, bracket)
+			if ex.cutStart {
+				snippet = "…" + snippet
+			}
+			if ex.cutEnd {
+				snippet += "…"
+			}
+			fmt.Printf("   Matched in %s: %s\n", strings.ReplaceAll(ex.field, "_", " "), snippet)
+		}
+		if via := synonymSummary(match.Terms); via != "" {
+			fmt.Printf("   %s\n", via)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/Nexusrex18/medCli/internal/config"
+)
+
+// configActions are the verbs of `medCli config`
+var configActions = map[string]func(args []string) error{
+	"path":     runConfigPath,
+	"show":     runConfigShow,
+	"get":      runConfigGet,
+	"set":      runConfigSet,
+	"validate": runConfigValidate,
+	"init":     runConfigInit,
+}
+
+func runConfig(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: medCli config path|show|get|set|validate|init")
+	}
+	action, ok := configActions[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown config action %q (want path, show, get, set, validate or init)", args[0])
+	}
+	return action(args[1:])
+}
+
+// loadConfigQuietly loads the configuration without the discovery log lines
+func loadConfigQuietly() (*config.Loaded, error) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+	return config.Load(configOptions)
+}
+
+func runConfigPath(args []string) error {
+	loaded, err := loadConfigQuietly()
+	if err != nil {
+		return err
+	}
+
+	if loaded.File != "" {
+		fmt.Printf("Active config: %s\n", absPath(loaded.File))
+	} else {
+		fmt.Println("Active config: none (using defaults)")
+	}
+	fmt.Printf("Writes go to:  %s\n", config.UserConfigPath())
+	dataFile := "none"
+	if loaded.Config.CSV.FilePath != "" {
+		dataFile = absPath(loaded.Config.CSV.FilePath)
+	}
+	fmt.Printf("Data file:     %s (%s)\n", dataFile, loaded.DataRule)
+	fmt.Println("Search order:")
+	for _, path := range config.SearchPaths {
+		fmt.Printf("  %s\n", absPath(filepath.Join(os.ExpandEnv(path), "config.yaml")))
+	}
+	return nil
+}
+
+func runConfigShow(args []string) error {
+	fs := flag.NewFlagSet("config show", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "print settings as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	loaded, err := loadConfigQuietly()
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		type setting struct {
+			Key    string      `json:"key"`
+			Value  interface{} `json:"value"`
+			Source string      `json:"source"`
+		}
+		settings := make([]setting, len(loaded.Settings))
+		for i, s := range loaded.Settings {
+			settings[i] = setting{Key: s.Key, Value: s.Value, Source: s.Source}
+		}
+		return printJSON(map[string]any{"file": loaded.File, "settings": settings})
+	}
+
+	if loaded.File != "" {
+		fmt.Printf("# %s\n", absPath(loaded.File))
+	} else {
+		fmt.Println("# no config file, using defaults")
+	}
+	for _, s := range loaded.Settings {
+		source := s.Source
+		if source == config.SourceEnv && s.Key == "csv.file_path" && os.Getenv(config.DataEnv) != "" {
+			source += " " + config.DataEnv
+		} else if source == config.SourceEnv {
+			source += " " + s.EnvVar()
+		}
+		fmt.Printf("%-22s = %-32s (%s)\n", s.Key, s.Format(s.Value), source)
+	}
+	return nil
+}
+
+func runConfigGet(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: medCli config get <key>")
+	}
+	field, ok := config.LookupField(args[0])
+	if !ok {
+		return fmt.Errorf("unknown config key %q", args[0])
+	}
+
+	loaded, err := loadConfigQuietly()
+	if err != nil {
+		return err
+	}
+	setting, _ := loaded.Setting(field.Key)
+	fmt.Println(setting.Format(setting.Value))
+	return nil
+}
+
+func runConfigSet(args []string) error {
+	if len(args) != 2 {
+		return errors.New("usage: medCli config set <key> <value>")
+	}
+	if err := config.Set(args[0], args[1]); err != nil {
+		return err
+	}
+
+	path := config.UserConfigPath()
+	fmt.Printf("Set %s = %s in %s\n", args[0], args[1], path)
+
+	// A config file earlier in the search order hides the user file
+	if loaded, err := loadConfigQuietly(); err == nil && loaded.File != "" && absPath(loaded.File) != path {
+		fmt.Fprintf(os.Stderr, "Note: %s is loaded first and takes precedence\n", absPath(loaded.File))
+	}
+	return nil
+}
+
+func runConfigValidate(args []string) error {
+	loaded, err := loadConfigQuietly()
+	if err != nil {
+		return err
+	}
+
+	if err := reportInvalid(loaded.Config); err != nil {
+		return err
+	}
+	fmt.Println("Configuration is valid")
+	return nil
+}
+
+// reportInvalid lists the invalid values of cfg on stderr and returns an
+// error counting them, or nil when cfg is valid
+func reportInvalid(cfg *config.Config) error {
+	var errs config.ValidationErrors
+	if !errors.As(config.Validate(cfg), &errs) {
+		return nil
+	}
+	for _, fieldErr := range errs {
+		fmt.Fprintf(os.Stderr, "  %s\n", fieldErr)
+	}
+	return fmt.Errorf("configuration has %d invalid value(s)", len(errs))
+}
+
+func runConfigInit(args []string) error {
+	fs := flag.NewFlagSet("config init", flag.ContinueOnError)
+	force := fs.Bool("force", false, "overwrite an existing config file")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	path, err := config.Init(*force)
+	if errors.Is(err, config.ErrConfigExists) {
+		return fmt.Errorf("%w (use --force to overwrite)", err)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Wrote default configuration to %s\n", path)
+	return nil
+}
+
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Nexusrex18/medCli/internal/models"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/reflow/ansi"
+)
+
+// openDetail shows the selected record in the detail popup, scrolled to the top
+func (m *model) openDetail() {
+	m.selectedRecord = &m.currentRecords[m.selectedIndex]
+	m.showPopup = true
+	m.layoutDetail()
+	m.detail.GotoTop()
+}
+
+// layoutDetail renders the selected record into the detail viewport for the
+// current popup size, keeping the scroll position
+func (m *model) layoutDetail() {
+	if m.selectedRecord == nil {
+		return
+	}
+	style := m.popup()
+	width := style.GetWidth() - style.GetHorizontalPadding()
+
+	m.detail.Width = width
+	m.detail.Height = max(style.GetHeight()-style.GetVerticalPadding()-lipgloss.Height(m.detailHeader(width))-2, 3)
+	m.detail.SetContent(m.detailContent(width))
+}
+
+// updateDetail handles keys in the detail popup. Keys it does not use
+// scroll the record.
+func (m model) updateDetail(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch {
+	case key.Matches(keyMsg, m.keys.Detail.Close):
+		m.showPopup = false
+		m.selectedRecord = nil
+		// Return to the results the popup was opened from
+		m.state = m.resultsState
+		if m.state == StateBookmarks && m.lastSearchType == "bookmarks" {
+			// Drop the bookmarks removed in the popup
+			return m, m.refreshBookmarks()
+		}
+		return m, nil
+	case key.Matches(keyMsg, m.keys.Detail.Similar):
+		// Jump to the records most similar to this one
+		source := *m.selectedRecord
+		m.similarSource = &source
+		m.lastSearchType = "similar"
+		m.pageOffset = 0
+		m.groupedView = false
+		m.showPopup = false
+		m.selectedRecord = nil
+		m.state = m.resultsState
+		return m, m.startSearch()
+	case key.Matches(keyMsg, m.keys.Detail.Star):
+		if m.bookmarks != nil {
+			_, m.bookmarkErr = m.bookmarks.Toggle(*m.selectedRecord)
+		}
+		m.layoutDetail()
+		return m, nil
+	case key.Matches(keyMsg, m.keys.Detail.Next):
+		return m, m.stepDetail(1)
+	case key.Matches(keyMsg, m.keys.Detail.Prev):
+		return m, m.stepDetail(-1)
+	}
+
+	var cmd tea.Cmd
+	m.detail, cmd = m.detail.Update(msg)
+	return m, cmd
+}
+
+// stepDetail shows the next or previous record in the whole result set,
+// fetching its page when needed
+func (m *model) stepDetail(delta int) tea.Cmd {
+	if m.searching {
+		return nil
+	}
+	index := m.pageOffset + m.selectedIndex + delta
+	if index < 0 || index >= m.totalResults {
+		return nil
+	}
+	cmd := m.selectResult(index)
+	if cmd == nil {
+		m.openDetail()
+	}
+	return cmd
+}
+
+func (m model) detailHeader(width int) string {
+	position := fmt.Sprintf("Item %d of %d", m.pageOffset+m.selectedIndex+1, m.totalResults) + m.starLabel(*m.selectedRecord)
+	if m.searching {
+		position = m.spinner.View() + " Loading…"
+	} else if !m.detail.AtTop() || !m.detail.AtBottom() {
+		position += fmt.Sprintf(" • %3.0f%%", m.detail.ScrollPercent()*100)
+	}
+	return lipgloss.JoinVertical(lipgloss.Left,
+		popupTitleStyle.Copy().Width(width).Render(m.selectedRecord.TM2Title),
+		resultMutedStyle.Render(position),
+		"",
+	)
+}
+
+// detailContent lists every field of the selected record
+func (m model) detailContent(width int) string {
+	record := m.selectedRecord
+
+	// Highlight the matched terms when the record came from a symptom search
+	var match models.SymptomMatch
+	if m.lastSearchType == "symptoms" && m.selectedIndex < len(m.currentMatches) {
+		match = m.currentMatches[m.selectedIndex]
+	}
+	highlighted := func(text, field string) string {
+		if text == "" || len(match.HighlightsFor(field)) == 0 {
+			return popupTextStyle.Render(wrapText(text, width, ""))
+		}
+		return popupTextStyle.Copy().Width(width).Render(renderHighlighted(text, match.HighlightsFor(field), popupTextStyle))
+	}
+
+	link := record.TM2Link
+	if link == "" {
+		link = "Not available"
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left,
+		popupSectionStyle.Render("Code Information:"),
+		popupTextStyle.Render(fmt.Sprintf("   TM2 Code: %s", record.TM2Code)),
+		popupTextStyle.Render(fmt.Sprintf("   Traditional Code: %s", record.Code)),
+		popupTextStyle.Render(fmt.Sprintf("   Medicine Type: %s", record.Type)),
+		popupTextStyle.Render(fmt.Sprintf("   Confidence Score: %.1f%%", record.ConfidenceScore*100)),
+		popupTextStyle.Copy().Width(width).Render("   TM2 Link: "+link),
+		"",
+		popupSectionStyle.Render("TM2 Title:"),
+		highlighted(record.TM2Title, "tm2_title"),
+		"",
+		popupSectionStyle.Render("TM2 Definition:"),
+		highlighted(record.TM2Definition, "tm2_definition"),
+		"",
+		popupSectionStyle.Render("Code Title:"),
+		highlighted(record.CodeTitle, "code_title"),
+		"",
+		popupSectionStyle.Render("Traditional Description:"),
+		highlighted(record.Description, "code_description"),
+	)
+}
+
+func (m model) renderPopup() string {
+	style := m.popup()
+	width := style.GetWidth() - style.GetHorizontalPadding()
+
+	content := lipgloss.JoinVertical(lipgloss.Left,
+		m.detailHeader(width),
+		m.detail.View(),
+		"",
+		lipgloss.PlaceHorizontal(width, lipgloss.Center, m.keyHints(width)),
+	)
+	popup := style.Render(content)
+
+	// The popup leaves a line free below it for a clickable link
+	if m.selectedRecord.TM2Link != "" {
+		indent := max((m.width-lipgloss.Width(popup))/2, 0)
+		link := linkLine(m.selectedRecord.TM2Link, indent, m.width,
+			"🔗 Open "+m.selectedRecord.TM2Code+" in the ICD-11 browser", "🔗 Open TM2 link")
+		if link != "" {
+			return lipgloss.Place(m.width, m.viewHeight()-1, lipgloss.Center, lipgloss.Center, popup) + "\n" + link
+		}
+	}
+	return m.place(popup)
+}
+
+// hyperlink wraps text in an OSC 8 sequence so terminals open url when it
+// is clicked
+func hyperlink(url, text string) string {
+	return "\x1b]8;;" + url + "\x1b\\" + text + "\x1b]8;;\x1b\\"
+}
+
+// linkLine is a hyperlink to url with the first label that fits in width
+// after indent. The renderer counts the escape sequence as printable text
+// and cuts lines it thinks are too wide, so the line is measured the same
+// way, and left out when no label fits.
+func linkLine(url string, indent, width int, labels ...string) string {
+	for _, label := range labels {
+		line := strings.Repeat(" ", indent) + resultSubtitleStyle.Render(hyperlink(url, label))
+		if ansi.PrintableRuneWidth(line) <= width {
+			return line
+		}
+	}
+	return ""
+}
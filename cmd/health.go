@@ -0,0 +1,221 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/Nexusrex18/medCli/internal/models"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// healthRefreshInterval is how often the dashboard refreshes itself when
+// display.auto_refresh is on
+const healthRefreshInterval = 2 * time.Second
+
+// sparkBars are the bar heights of a sparkline, lowest first
+var sparkBars = []rune("▁▂▃▄▅▆▇█")
+
+// healthTickMsg asks the dashboard to refresh. id ties it to the refresh
+// loop that sent it so only the latest loop keeps running.
+type healthTickMsg struct {
+	id int
+}
+
+// openHealth shows the health dashboard and starts refreshing it
+func (m *model) openHealth() tea.Cmd {
+	m.state = StateHealth
+	m.layoutHealth()
+	m.health.GotoTop()
+	return m.healthTick()
+}
+
+// healthTick starts a new refresh loop, replacing any running one. It
+// returns nil when auto refresh is off.
+func (m *model) healthTick() tea.Cmd {
+	m.healthID++
+	if !m.config.Display.AutoRefresh {
+		return nil
+	}
+	id := m.healthID
+	return tea.Tick(healthRefreshInterval, func(time.Time) tea.Msg {
+		return healthTickMsg{id: id}
+	})
+}
+
+// refreshHealth handles a healthTickMsg, ending the loop once the
+// dashboard is closed or auto refresh is switched off
+func (m model) refreshHealth(msg healthTickMsg) (tea.Model, tea.Cmd) {
+	if m.state != StateHealth || msg.id != m.healthID {
+		return m, nil
+	}
+	m.layoutHealth()
+	return m, m.healthTick()
+}
+
+// layoutHealth renders fresh stats into the dashboard viewport for the
+// current terminal size, keeping the scroll position
+func (m *model) layoutHealth() {
+	if m.client == nil {
+		return
+	}
+	box := m.box()
+	m.health.Width = m.textWidth()
+	m.health.Height = max(m.pageHeight()-box.GetVerticalPadding(), 1)
+	m.health.SetContent(lipgloss.NewStyle().Width(m.textWidth()).Render(m.healthContent(m.textWidth())))
+}
+
+// updateHealth handles keys on the dashboard. Keys it does not use scroll
+// the stats.
+func (m model) updateHealth(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case key.Matches(msg, m.keys.Health.Back):
+			m.state = StateMenu
+			return m, nil
+		case key.Matches(msg, m.keys.Health.Refresh):
+			m.layoutHealth()
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.health, cmd = m.health.Update(msg)
+	return m, cmd
+}
+
+func (m model) viewHealth() string {
+	return m.place(m.join(lipgloss.Left,
+		m.title("📊 Health Status Dashboard"),
+		"",
+		m.box().Height(m.pageHeight()).Render(m.health.View()),
+		"",
+		m.footer(),
+	))
+}
+
+// healthContent lists the dataset, index, cache, latency and memory stats
+func (m model) healthContent(width int) string {
+	stats := m.client.GetRepoStats()
+	cacheStats := m.client.GetCacheStats()
+	loadedAt, loadTime := m.client.Metrics().LastLoad()
+	latencies := m.client.Metrics().RecentLatencies()
+	uptime := time.Since(m.startTime).Truncate(time.Second)
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	refreshed := fmt.Sprintf("Press %s to refresh", m.keys.Health.Refresh.Help().Key)
+	if m.config.Display.AutoRefresh {
+		refreshed = fmt.Sprintf("Refreshes every %s", healthRefreshInterval)
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left,
+		resultTitleStyle.Render("📊 System Health Status"),
+		"",
+		resultSubtitleStyle.Render("📈 Data Statistics:"),
+		resultTextStyle.Render(fmt.Sprintf("   📄 File: %s", filepath.Base(m.config.CSV.FilePath))),
+		resultMutedStyle.Render(fmt.Sprintf("      chosen by %s", m.dataRule)),
+		resultTextStyle.Render(fmt.Sprintf("   📚 Total Records: %d", stats["total_records"])),
+		resultTextStyle.Render(fmt.Sprintf("   🏷️  Unique Codes: %d", stats["unique_codes"])),
+		resultTextStyle.Render(fmt.Sprintf("   🔢 Unique TM2 Codes: %d", stats["unique_tm2_codes"])),
+		resultTextStyle.Render(fmt.Sprintf("   ⏳ Loaded: %s in %s", loadedAt.Format("15:04:05"), shortDuration(loadTime))),
+		"",
+		resultSubtitleStyle.Render("🗂️  Index Sizes:"),
+		resultTextStyle.Render(fmt.Sprintf("   Code index: %d keys • TM2 index: %d keys", stats["unique_codes"], stats["unique_tm2_codes"])),
+		resultTextStyle.Render(fmt.Sprintf("   Term index: %d terms • %d postings", stats["indexed_terms"], stats["term_postings"])),
+		"",
+		resultSubtitleStyle.Render("🔗 Mapping Cardinality:"),
+		resultTextStyle.Render(fmt.Sprintf("   One-to-one: %d • One-to-many: %d", stats[string(models.OneToOne)], stats[string(models.OneToMany)])),
+		resultTextStyle.Render(fmt.Sprintf("   Many-to-one: %d • Many-to-many: %d", stats[string(models.ManyToOne)], stats[string(models.ManyToMany)])),
+		"",
+		resultSubtitleStyle.Render("⚡ Performance:"),
+		resultTextStyle.Render(fmt.Sprintf("   🎯 Cache Hit Ratio: %s", hitRatio(cacheStats.Hits, cacheStats.Misses))),
+		resultTextStyle.Render(fmt.Sprintf("   💾 Cache Hits: %d • Misses: %d", cacheStats.Hits, cacheStats.Misses)),
+		resultTextStyle.Render(fmt.Sprintf("   📦 Cache Items: %s", cacheItems(cacheStats))),
+		resultTextStyle.Render(fmt.Sprintf("   🗑️  Cache Evictions: %d", cacheStats.Evictions)),
+		resultTextStyle.Render("   📉 Recent Query Latency:"),
+		latencyLines(latencies, width-6),
+		"",
+		resultSubtitleStyle.Render("🧠 Memory:"),
+		resultTextStyle.Render(fmt.Sprintf("   Heap in use: %s • Reserved: %s", formatBytes(mem.HeapAlloc), formatBytes(mem.Sys))),
+		resultTextStyle.Render(fmt.Sprintf("   Goroutines: %d • GC runs: %d", runtime.NumGoroutine(), mem.NumGC)),
+		"",
+		resultTextStyle.Render(fmt.Sprintf("⏱️  Uptime: %s", uptime)),
+		resultMutedStyle.Render(fmt.Sprintf("💡 Updated %s • %s", time.Now().Format("15:04:05"), refreshed)),
+	)
+}
+
+// latencyLines is a sparkline of the most recent latencies that fit in
+// width, followed by their average and maximum
+func latencyLines(latencies []time.Duration, width int) string {
+	if len(latencies) == 0 {
+		return resultMutedStyle.Render("      No searches yet")
+	}
+	if width > 0 && len(latencies) > width {
+		latencies = latencies[len(latencies)-width:]
+	}
+
+	var total, peak time.Duration
+	for _, latency := range latencies {
+		total += latency
+		if latency > peak {
+			peak = latency
+		}
+	}
+	return lipgloss.JoinVertical(lipgloss.Left,
+		"      "+resultSubtitleStyle.Render(sparkline(latencies, peak)),
+		resultMutedStyle.Render(fmt.Sprintf("      last %d • avg %s • max %s",
+			len(latencies), shortDuration(total/time.Duration(len(latencies))), shortDuration(peak))),
+	)
+}
+
+// sparkline draws one bar per value, scaled so peak is the tallest
+func sparkline(values []time.Duration, peak time.Duration) string {
+	var b strings.Builder
+	for _, v := range values {
+		level := 0
+		if peak > 0 {
+			level = int(float64(v) / float64(peak) * float64(len(sparkBars)-1))
+		}
+		b.WriteRune(sparkBars[level])
+	}
+	return b.String()
+}
+
+// hitRatio is the share of cache lookups that were hits
+func hitRatio(hits, misses int) string {
+	if hits+misses == 0 {
+		return "n/a"
+	}
+	return fmt.Sprintf("%.1f%%", float64(hits)/float64(hits+misses)*100)
+}
+
+// shortDuration rounds d to a precision that reads well on the dashboard
+func shortDuration(d time.Duration) string {
+	switch {
+	case d >= time.Second:
+		return d.Round(10 * time.Millisecond).String()
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond).String()
+	default:
+		return d.Round(time.Microsecond).String()
+	}
+}
+
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := uint64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"embed"
+	"fmt"
+	"strings"
+
+	"github.com/Nexusrex18/medCli/internal/keymap"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+//go:embed help/*.md
+var helpPages embed.FS
+
+// helpTopic is a page of the help browser. The page without a file lists
+// the key bindings in use.
+type helpTopic struct {
+	title string
+	file  string
+}
+
+var helpTopics = []helpTopic{
+	{title: "Keys"},
+	{title: "Search syntax", file: "help/search.md"},
+	{title: "Code formats", file: "help/codes.md"},
+	{title: "Data sources", file: "help/data.md"},
+}
+
+// openHelp shows the help browser at its first topic
+func (m *model) openHelp() {
+	m.state = StateHelp
+	m.helpTopic = 0
+	m.layoutHelp()
+	m.helpPage.GotoTop()
+}
+
+// layoutHelp renders the current topic into the help viewport for the
+// current terminal size
+func (m *model) layoutHelp() {
+	box := m.box()
+	m.helpPage.Width = m.textWidth()
+	// The topic tabs and a blank line sit above the page
+	m.helpPage.Height = max(m.pageHeight()-box.GetVerticalPadding()-2, 1)
+	m.helpPage.SetContent(renderHelpPage(m.helpSource(), m.textWidth()))
+}
+
+// helpSource is the text of the current topic
+func (m model) helpSource() string {
+	topic := helpTopics[m.helpTopic]
+	if topic.file == "" {
+		return m.keysPage()
+	}
+	text, err := helpPages.ReadFile(topic.file)
+	if err != nil {
+		return err.Error()
+	}
+	return string(text)
+}
+
+// keysPage lists the keys of every action, screen by screen, under the
+// names the keys section of the config uses
+func (m model) keysPage() string {
+	var b strings.Builder
+	b.WriteString("# Keyboard shortcuts\n")
+	b.WriteString("\nAny of these can be rebound in the keys section of the config file. Bindings marked * are set there.\n")
+
+	group := ""
+	for _, action := range keymap.Actions {
+		if action.Group() != group {
+			group = action.Group()
+			fmt.Fprintf(&b, "\n## %s — keys.%s\n", keyGroupTitles[group], group)
+		}
+		desc := action.Help
+		if m.keys.custom[action.Name] {
+			desc += " *"
+		}
+		_, name, _ := strings.Cut(action.Name, ".")
+		fmt.Fprintf(&b, "    %-15s %-17s %s\n", name, keyNames(m.keys.bound[action.Name]), desc)
+	}
+	return b.String()
+}
+
+// keyGroupTitles names the screen each group of actions is used on
+var keyGroupTitles = map[string]string{
+	"global":   "Everywhere",
+	"intro":    "Welcome screen",
+	"menu":     "Menu",
+	"search":   "Search screens",
+	"history":  "Search history",
+	"list":     "Result list",
+	"jump":     "Go to result",
+	"detail":   "Record details",
+	"scroll":   "Scrolling",
+	"health":   "Health dashboard",
+	"settings": "Settings",
+	"help":     "Help",
+}
+
+// keyNames lists keys as they are written in the config, or "unbound"
+func keyNames(keys []string) string {
+	if len(keys) == 0 {
+		return "unbound"
+	}
+	names := make([]string, len(keys))
+	for i, k := range keys {
+		names[i] = keymap.Name(k)
+	}
+	return strings.Join(names, ", ")
+}
+
+// updateHelp handles keys in the help browser. Keys it does not use
+// scroll the page.
+func (m model) updateHelp(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case key.Matches(msg, m.keys.Help.Back):
+			m.state = StateMenu
+			return m, nil
+		case key.Matches(msg, m.keys.Help.Next):
+			m.helpTopic = (m.helpTopic + 1) % len(helpTopics)
+			m.layoutHelp()
+			m.helpPage.GotoTop()
+			return m, nil
+		case key.Matches(msg, m.keys.Help.Prev):
+			m.helpTopic = (m.helpTopic + len(helpTopics) - 1) % len(helpTopics)
+			m.layoutHelp()
+			m.helpPage.GotoTop()
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.helpPage, cmd = m.helpPage.Update(msg)
+	return m, cmd
+}
+
+func (m model) viewHelp() string {
+	tabs := make([]string, len(helpTopics))
+	for i, topic := range helpTopics {
+		if i == m.helpTopic {
+			tabs[i] = menuSelectedStyle.Render(topic.title)
+		} else {
+			tabs[i] = menuItemStyle.Render(topic.title)
+		}
+	}
+	tabRow := lipgloss.NewStyle().MaxWidth(m.textWidth()).Render(lipgloss.JoinHorizontal(lipgloss.Top, tabs...))
+
+	return m.place(m.join(lipgloss.Left,
+		m.title("📖 Help & Documentation"),
+		"",
+		m.box().Height(m.pageHeight()).Render(lipgloss.JoinVertical(lipgloss.Left, tabRow, "", m.helpPage.View())),
+		"",
+		m.footer(),
+	))
+}
+
+// renderHelpPage styles a help page written in a small subset of
+// Markdown: # and ## headings, "- " bullets, lines indented by four
+// spaces for examples, and paragraphs of one line each
+func renderHelpPage(text string, width int) string {
+	var lines []string
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "# "):
+			lines = append(lines, resultTitleStyle.Render(strings.TrimPrefix(line, "# ")))
+		case strings.HasPrefix(line, "## "):
+			lines = append(lines, resultSubtitleStyle.Render(strings.TrimPrefix(line, "## ")))
+		case strings.HasPrefix(line, "    "):
+			lines = append(lines, codeStyle.Copy().MaxWidth(width).Render(line))
+		case strings.HasPrefix(line, "- "):
+			item := resultTextStyle.Copy().Width(max(width-2, 1)).Render(strings.TrimPrefix(line, "- "))
+			for i, itemLine := range strings.Split(item, "\n") {
+				prefix := "  "
+				if i == 0 {
+					prefix = "• "
+				}
+				lines = append(lines, prefix+itemLine)
+			}
+		case strings.TrimSpace(line) == "":
+			lines = append(lines, "")
+		default:
+			lines = append(lines, resultTextStyle.Copy().Width(width).Render(line))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// footer is the key help line below every screen, cut to the width of
+// the boxes above it
+func (m model) footer() string {
+	return m.keyHints(min(m.boxWidth()+2, m.width))
+}
+
+// keyHints is the short key help for the current screen in width columns
+func (m model) keyHints(width int) string {
+	hints := m.help
+	hints.Width = width
+	return hints.ShortHelpView(m.keyHelp().ShortHelp())
+}
+
+// renderHelpOverlay lists every binding that applies to the screen below
+// it
+func (m model) renderHelpOverlay() string {
+	style := m.popup().UnsetHeight()
+	width := style.GetWidth() - style.GetHorizontalPadding()
+
+	full := m.help
+	full.Width = width
+	content := lipgloss.JoinVertical(lipgloss.Left,
+		popupTitleStyle.Copy().Width(width).Render("⌨️  Keyboard Shortcuts"),
+		"",
+		full.FullHelpView(m.keyHelp().FullHelp()),
+		"",
+		statusStyle.Render("Press any key to close"),
+	)
+	return m.place(style.Render(content))
+}
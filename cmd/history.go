@@ -0,0 +1,147 @@
+package main
+
+import (
+	"github.com/Nexusrex18/medCli/internal/history"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// recallState tracks the earlier search shown in the search input, and
+// the Ctrl-R reverse search
+type recallState struct {
+	queries   history.Recall
+	at        int  // position in queries of the query in the input, -1 when none is
+	searching bool // the reverse search is open
+	pattern   textinput.Model
+	match     int    // position in queries of the reverse search match, -1 when none
+	draft     string // the input before the reverse search, restored on cancel
+}
+
+func newRecallState() recallState {
+	pattern := textinput.New()
+	pattern.Prompt = "↺ "
+	pattern.Placeholder = "search history"
+	pattern.CharLimit = 156
+	return recallState{at: -1, match: -1, pattern: pattern}
+}
+
+// historyTypes are the search types recalled on the current screen
+func (m model) historyTypes() []string {
+	if m.state == StateSymptoms {
+		return []string{"symptoms", "query"}
+	}
+	return []string{"code"}
+}
+
+// updateRecall handles the history keys in the search input. Up and Down
+// only recall while the input is empty or still holds a recalled query,
+// so they do not get in the way of editing.
+func (m *model) updateRecall(msg tea.KeyMsg) (tea.Cmd, bool) {
+	if m.listing() || m.searching || m.history == nil {
+		return nil, false
+	}
+
+	if key.Matches(msg, m.keys.History.Search) {
+		m.recall.queries = m.history.NewRecall(m.historyTypes()...)
+		m.recall.searching = true
+		m.recall.draft = m.input.Value()
+		m.recall.pattern.Reset()
+		m.recall.pattern.Focus()
+		m.recall.match = m.recall.queries.Find("", 0)
+		return textinput.Blink, true
+	}
+
+	recalling := m.recall.at >= 0 && m.recall.at < m.recall.queries.Len() &&
+		m.input.Value() == m.recall.queries.At(m.recall.at)
+	if !recalling {
+		if m.input.Value() != "" {
+			return nil, false
+		}
+		m.recall.at = -1
+	}
+
+	switch {
+	case key.Matches(msg, m.keys.History.Prev):
+		if m.recall.at < 0 {
+			m.recall.queries = m.history.NewRecall(m.historyTypes()...)
+		}
+		if m.recall.at+1 < m.recall.queries.Len() {
+			m.recall.at++
+			m.setInput(m.recall.queries.At(m.recall.at))
+		}
+		return nil, true
+	case key.Matches(msg, m.keys.History.Next):
+		if m.recall.at < 0 {
+			return nil, true
+		}
+		m.recall.at--
+		if m.recall.at < 0 {
+			m.setInput("")
+		} else {
+			m.setInput(m.recall.queries.At(m.recall.at))
+		}
+		return nil, true
+	}
+	return nil, false
+}
+
+// updateReverseSearch handles keys while the reverse search is open.
+// Typing narrows the match, History.Search steps to older matches,
+// Search.Submit runs the match and Search.Cancel restores the input.
+func (m model) updateReverseSearch(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case key.Matches(msg, m.keys.Search.Cancel):
+			m.recall.searching = false
+			m.setInput(m.recall.draft)
+			return m, nil
+		case key.Matches(msg, m.keys.Search.Submit):
+			m.recall.searching = false
+			if m.recall.match < 0 {
+				m.setInput(m.recall.draft)
+				return m, nil
+			}
+			m.setInput(m.recall.queries.At(m.recall.match))
+			return m, m.newSearch()
+		case key.Matches(msg, m.keys.History.Search):
+			if m.recall.match >= 0 {
+				if older := m.recall.queries.Find(m.recall.pattern.Value(), m.recall.match+1); older >= 0 {
+					m.recall.match = older
+				}
+			}
+			return m, nil
+		}
+	}
+
+	before := m.recall.pattern.Value()
+	var cmd tea.Cmd
+	m.recall.pattern, cmd = m.recall.pattern.Update(msg)
+	if m.recall.pattern.Value() != before {
+		m.recall.match = m.recall.queries.Find(m.recall.pattern.Value(), 0)
+	}
+	return m, cmd
+}
+
+// setInput replaces the search input with value, cursor at the end
+func (m *model) setInput(value string) {
+	m.input.SetValue(value)
+	m.input.CursorEnd()
+}
+
+// searchInputView is the content of the search input box: the query, or
+// the reverse search pattern and its match
+func (m model) searchInputView() string {
+	if !m.recall.searching {
+		return m.input.View()
+	}
+
+	match := resultMutedStyle.Render("no match")
+	if m.recall.match >= 0 {
+		match = m.recall.queries.At(m.recall.match)
+	}
+	// The box must stay one line high
+	width := m.inputWidth() - inputStyle.GetHorizontalPadding()
+	return lipgloss.NewStyle().MaxWidth(width).Render(m.recall.pattern.View() + "  → " + match)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/Nexusrex18/medCli/internal/history"
+)
+
+// historyActions are the verbs of `medCli history`
+var historyActions = map[string]func(args []string) error{
+	"list":  runHistoryList,
+	"clear": runHistoryClear,
+}
+
+func runHistory(args []string) error {
+	if len(args) == 0 {
+		return runHistoryList(nil)
+	}
+	action, ok := historyActions[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown history action %q (want list or clear)", args[0])
+	}
+	return action(args[1:])
+}
+
+func runHistoryList(args []string) error {
+	fs := flag.NewFlagSet("history list", flag.ContinueOnError)
+	limit := fs.Int("limit", 0, "show only the latest searches (0 for all)")
+	searchType := fs.String("type", "", "show only searches of this type: code, symptoms or query")
+	asJSON := fs.Bool("json", false, "print the history as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return errors.New("usage: medCli history list [--limit n] [--type type] [--json]")
+	}
+
+	h, err := history.Open(history.Path())
+	if err != nil {
+		return err
+	}
+
+	// Numbered from the oldest search, as shells number their history
+	type numbered struct {
+		n     int
+		entry history.Entry
+	}
+	var shown []numbered
+	for i, entry := range h.Entries() {
+		if *searchType == "" || entry.Type == *searchType {
+			shown = append(shown, numbered{i + 1, entry})
+		}
+	}
+	if *limit > 0 && len(shown) > *limit {
+		shown = shown[len(shown)-*limit:]
+	}
+
+	if *asJSON {
+		entries := make([]history.Entry, len(shown))
+		for i, s := range shown {
+			entries[i] = s.entry
+		}
+		return printJSON(entries)
+	}
+	if len(shown) == 0 {
+		fmt.Println("No searches in history")
+		return nil
+	}
+	for _, s := range shown {
+		fmt.Printf("%5d  %s  %-8s  %s\n", s.n, s.entry.Time.Local().Format("2006-01-02 15:04"), s.entry.Type, s.entry.Query)
+	}
+	return nil
+}
+
+func runHistoryClear(args []string) error {
+	if len(args) != 0 {
+		return errors.New("usage: medCli history clear")
+	}
+
+	h, err := history.Open(history.Path())
+	if err != nil {
+		return err
+	}
+	n := len(h.Entries())
+	if err := h.Clear(); err != nil {
+		return err
+	}
+	fmt.Printf("Cleared %d searches from %s\n", n, h.Path())
+	return nil
+}
@@ -0,0 +1,453 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Nexusrex18/medCli/internal/keymap"
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// keyMap holds the key bindings of every screen, built from the actions
+// of the keymap package. Update matches keys against it rather than
+// against literal strings, and the help footer, overlay and browser are
+// rendered from it.
+type keyMap struct {
+	Global    globalKeys
+	Intro     introKeys
+	Menu      menuKeys
+	Search    searchKeys
+	History   historyKeys
+	List      listKeys
+	Bookmarks bookmarksKeys
+	Jump      jumpKeys
+	Detail    detailKeys
+	Scroll    scrollKeys
+	Health    healthKeys
+	Settings  settingsKeys
+	Help      helpKeys
+
+	bound  map[string][]string // keys of every action, by keymap name
+	custom map[string]bool     // actions rebound in the config
+}
+
+// globalKeys work on every screen
+type globalKeys struct {
+	Help key.Binding
+	Quit key.Binding
+}
+
+type introKeys struct {
+	Continue key.Binding
+	Quit     key.Binding
+}
+
+type menuKeys struct {
+	Up     key.Binding
+	Down   key.Binding
+	Select key.Binding
+	Quit   key.Binding
+}
+
+// searchKeys are used on both search screens
+type searchKeys struct {
+	Submit  key.Binding // search, or open the selected result
+	Grouped key.Binding
+	Cancel  key.Binding
+	Back    key.Binding
+}
+
+// historyKeys recall earlier searches into an empty search input
+type historyKeys struct {
+	Prev   key.Binding
+	Next   key.Binding
+	Search key.Binding // reverse search, or the next older match while searching
+}
+
+// listKeys move through a result list
+type listKeys struct {
+	Up       key.Binding
+	Down     key.Binding
+	PageUp   key.Binding
+	PageDown key.Binding
+	Home     key.Binding
+	End      key.Binding
+	Jump     key.Binding
+	Star     key.Binding // bookmark the selected record, or remove its bookmark
+}
+
+// bookmarksKeys are used on the Bookmarks screen, where keys that type
+// text go to the filter
+type bookmarksKeys struct {
+	Remove key.Binding
+}
+
+// jumpKeys are used while a result number is typed after List.Jump
+type jumpKeys struct {
+	Go     key.Binding
+	Cancel key.Binding
+}
+
+type detailKeys struct {
+	Close   key.Binding
+	Next    key.Binding
+	Prev    key.Binding
+	Similar key.Binding
+	Star    key.Binding
+}
+
+// scrollKeys scroll the detail popup, health dashboard and help pages
+type scrollKeys struct {
+	Up           key.Binding
+	Down         key.Binding
+	PageUp       key.Binding
+	PageDown     key.Binding
+	HalfPageUp   key.Binding
+	HalfPageDown key.Binding
+}
+
+type healthKeys struct {
+	Refresh key.Binding
+	Back    key.Binding
+}
+
+type settingsKeys struct {
+	Up     key.Binding
+	Down   key.Binding
+	Edit   key.Binding
+	Save   key.Binding
+	Cancel key.Binding
+	Back   key.Binding
+}
+
+// helpKeys switch between the topics of the help browser
+type helpKeys struct {
+	Next key.Binding
+	Prev key.Binding
+	Back key.Binding
+}
+
+// loadKeys returns the key bindings with the keys section of the config
+// applied, or the default bindings together with the reason the section
+// could not be applied
+func loadKeys(overrides map[string]map[string][]string) (keyMap, error) {
+	bound, err := keymap.Resolve(overrides)
+	if err != nil {
+		return newKeyMap(keymap.Defaults(), nil), fmt.Errorf("key bindings not applied: %w", err)
+	}
+	custom := make(map[string]bool)
+	for group, actions := range overrides {
+		for action := range actions {
+			custom[group+"."+action] = true
+		}
+	}
+	return newKeyMap(bound, custom), nil
+}
+
+// newKeyMap builds the bindings from the keys of every action, named as in
+// the keymap package. custom marks the actions set in the config.
+func newKeyMap(bound map[string][]string, custom map[string]bool) keyMap {
+	bind := func(name string) key.Binding {
+		action, _ := keymap.Lookup(name)
+		keys := bound[name]
+		return key.NewBinding(key.WithKeys(keys...), key.WithHelp(keyLabel(keys), action.Help))
+	}
+
+	return keyMap{
+		Global: globalKeys{
+			Help: bind("global.help"),
+			Quit: bind("global.quit"),
+		},
+		Intro: introKeys{
+			Continue: bind("intro.continue"),
+			Quit:     bind("intro.quit"),
+		},
+		Menu: menuKeys{
+			Up:     bind("menu.up"),
+			Down:   bind("menu.down"),
+			Select: bind("menu.select"),
+			Quit:   bind("menu.quit"),
+		},
+		Search: searchKeys{
+			Submit:  bind("search.submit"),
+			Grouped: bind("search.grouped"),
+			Cancel:  bind("search.cancel"),
+			Back:    bind("search.back"),
+		},
+		History: historyKeys{
+			Prev:   bind("history.prev"),
+			Next:   bind("history.next"),
+			Search: bind("history.search"),
+		},
+		List: listKeys{
+			Up:       bind("list.up"),
+			Down:     bind("list.down"),
+			PageUp:   bind("list.page_up"),
+			PageDown: bind("list.page_down"),
+			Home:     bind("list.home"),
+			End:      bind("list.end"),
+			Jump:     bind("list.jump"),
+			Star:     bind("list.star"),
+		},
+		Bookmarks: bookmarksKeys{
+			Remove: bind("bookmarks.remove"),
+		},
+		Jump: jumpKeys{
+			Go:     bind("jump.go"),
+			Cancel: bind("jump.cancel"),
+		},
+		Detail: detailKeys{
+			Close:   bind("detail.close"),
+			Next:    bind("detail.next"),
+			Prev:    bind("detail.prev"),
+			Similar: bind("detail.similar"),
+			Star:    bind("detail.star"),
+		},
+		Scroll: scrollKeys{
+			Up:           bind("scroll.up"),
+			Down:         bind("scroll.down"),
+			PageUp:       bind("scroll.page_up"),
+			PageDown:     bind("scroll.page_down"),
+			HalfPageUp:   bind("scroll.half_page_up"),
+			HalfPageDown: bind("scroll.half_page_down"),
+		},
+		Health: healthKeys{
+			Refresh: bind("health.refresh"),
+			Back:    bind("health.back"),
+		},
+		Settings: settingsKeys{
+			Up:     bind("settings.up"),
+			Down:   bind("settings.down"),
+			Edit:   bind("settings.edit"),
+			Save:   bind("settings.save"),
+			Cancel: bind("settings.cancel"),
+			Back:   bind("settings.back"),
+		},
+		Help: helpKeys{
+			Next: bind("help.next"),
+			Prev: bind("help.prev"),
+			Back: bind("help.back"),
+		},
+		bound:  bound,
+		custom: custom,
+	}
+}
+
+// keySymbols are shorter labels for keys shown in the key help
+var keySymbols = map[string]string{
+	"up":     "↑",
+	"down":   "↓",
+	"left":   "←",
+	"right":  "→",
+	"pgdown": "pgdn",
+	" ":      "space",
+}
+
+// keyLabel is the key help label for keys, showing the first two
+func keyLabel(keys []string) string {
+	if len(keys) > 2 {
+		keys = keys[:2]
+	}
+	labels := make([]string, len(keys))
+	for i, k := range keys {
+		if symbol, ok := keySymbols[k]; ok {
+			k = symbol
+		}
+		labels[i] = k
+	}
+	return strings.Join(labels, "/")
+}
+
+// setKeys switches every screen to keys, including the components that
+// keep their own copies of the bindings
+func (m *model) setKeys(keys keyMap) {
+	m.keys = keys
+	m.menu.KeyMap = keys.listKeyMap()
+	m.detail.KeyMap = keys.viewportKeyMap()
+	m.health.KeyMap = keys.viewportKeyMap()
+	m.helpPage.KeyMap = keys.viewportKeyMap()
+}
+
+// joinWarnings is the banner text for the config errors that were worked
+// around, or empty when there are none
+func joinWarnings(errs ...error) string {
+	var msgs []string
+	for _, err := range errs {
+		if err != nil {
+			msgs = append(msgs, err.Error())
+		}
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// listKeyMap is the menu's list.KeyMap with its cursor moved by k and the
+// list's own filtering, help and quit keys switched off
+func (k keyMap) listKeyMap() list.KeyMap {
+	km := list.DefaultKeyMap()
+	km.CursorUp = k.Menu.Up
+	km.CursorDown = k.Menu.Down
+	for _, b := range []*key.Binding{&km.Filter, &km.ClearFilter, &km.ShowFullHelp, &km.CloseFullHelp, &km.Quit, &km.ForceQuit} {
+		b.SetEnabled(false)
+	}
+	return km
+}
+
+// viewportKeyMap is the viewport.KeyMap made of the scroll keys
+func (k keyMap) viewportKeyMap() viewport.KeyMap {
+	return viewport.KeyMap{
+		Up:           k.Scroll.Up,
+		Down:         k.Scroll.Down,
+		PageUp:       k.Scroll.PageUp,
+		PageDown:     k.Scroll.PageDown,
+		HalfPageUp:   k.Scroll.HalfPageUp,
+		HalfPageDown: k.Scroll.HalfPageDown,
+	}
+}
+
+// keyHelp is a help.KeyMap for one screen: the bindings shown in its
+// footer and the groups shown in the full help overlay
+type keyHelp struct {
+	short []key.Binding
+	full  [][]key.Binding
+}
+
+var _ help.KeyMap = keyHelp{}
+
+func (k keyHelp) ShortHelp() []key.Binding  { return k.short }
+func (k keyHelp) FullHelp() [][]key.Binding { return k.full }
+
+// described is b with its help description replaced, for bindings whose
+// action depends on what the screen shows
+func described(b key.Binding, desc string) key.Binding {
+	b.SetHelp(b.Help().Key, desc)
+	return b
+}
+
+// typesText reports whether msg is a key a text input would insert
+func typesText(msg tea.KeyMsg) bool {
+	return (msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace) && !msg.Alt
+}
+
+// untyped is b without the keys that type text, for screens where those go
+// to an input. It is disabled when every key of b types text.
+func untyped(b key.Binding) key.Binding {
+	var kept []string
+	for _, k := range b.Keys() {
+		if utf8.RuneCountInString(k) > 1 {
+			kept = append(kept, k)
+		}
+	}
+	if len(kept) == 0 {
+		b.SetEnabled(false)
+		return b
+	}
+	b.SetKeys(kept...)
+	b.SetHelp(keyLabel(kept), b.Help().Desc)
+	return b
+}
+
+// keyHelp describes the bindings that apply to what is on screen now
+func (m model) keyHelp() keyHelp {
+	k := m.keys
+	scroll := []key.Binding{k.Scroll.Up, k.Scroll.Down, k.Scroll.PageUp, k.Scroll.PageDown, k.Scroll.HalfPageUp, k.Scroll.HalfPageDown}
+	global := []key.Binding{k.Global.Help, k.Global.Quit}
+
+	var h keyHelp
+	switch {
+	case m.state == StateError:
+		h.short = []key.Binding{k.Intro.Quit}
+	case m.showIntro:
+		h.short = []key.Binding{k.Intro.Continue, k.Intro.Quit}
+	case m.state == StatePopup:
+		star := described(k.Detail.Star, m.starAction())
+		h.short = []key.Binding{k.Scroll.Down, k.Detail.Next, k.Detail.Prev, k.Detail.Similar, star, k.Detail.Close}
+		h.full = [][]key.Binding{{k.Detail.Next, k.Detail.Prev, k.Detail.Similar, star, k.Detail.Close}, scroll}
+	case m.jumping:
+		h.short = []key.Binding{k.Jump.Go, k.Jump.Cancel}
+	case m.recall.searching:
+		h.short = []key.Binding{described(k.History.Search, "older match"), described(k.Search.Submit, "run"), described(k.Search.Cancel, "cancel")}
+	case m.state == StateBookmarks:
+		leave := untyped(described(k.Search.Cancel, "back to menu"))
+		if m.input.Value() != "" {
+			leave = described(leave, "clear filter")
+		}
+		back := untyped(k.Search.Back)
+		if !m.listing() {
+			h.short = []key.Binding{leave, back}
+			break
+		}
+		up, down := untyped(k.List.Up), untyped(k.List.Down)
+		actions := []key.Binding{untyped(described(k.Search.Submit, "details")), leave, untyped(k.Bookmarks.Remove), back}
+		h.short = append([]key.Binding{up, down}, actions...)
+		h.full = [][]key.Binding{
+			{up, down, untyped(k.List.PageUp), untyped(k.List.PageDown), untyped(k.List.Home), untyped(k.List.End), untyped(k.List.Jump)},
+			actions,
+		}
+	case m.state == StateSearch || m.state == StateSymptoms:
+		// Keys that type text go to the search input on these screens
+		submit, leave, back := untyped(k.Search.Submit), untyped(described(k.Search.Cancel, "back to menu")), untyped(k.Search.Back)
+		up, down := untyped(k.List.Up), untyped(k.List.Down)
+		pageUp, pageDown := untyped(k.List.PageUp), untyped(k.List.PageDown)
+		home, end := untyped(k.List.Home), untyped(k.List.End)
+		switch {
+		case m.searching:
+			h.short = []key.Binding{untyped(k.Search.Cancel), back}
+		case m.grouped():
+			listView := untyped(described(k.Search.Grouped, "list view"))
+			up, down = described(up, "scroll up"), described(down, "scroll down")
+			pageUp, pageDown = described(pageUp, "page up"), described(pageDown, "page down")
+			h.short = []key.Binding{up, down, pageDown, listView, leave, back}
+			h.full = [][]key.Binding{{up, down, pageUp, pageDown, described(home, "top"), described(end, "bottom")}, {listView, leave, back}}
+		case m.viewingResults && m.groupedView:
+			h.short = []key.Binding{untyped(described(k.Search.Grouped, "list view")), leave, back}
+		case m.listing():
+			actions := []key.Binding{described(submit, "details"), untyped(described(k.List.Star, m.starAction()))}
+			if m.lastSearchType == "code" {
+				actions = append(actions, untyped(k.Search.Grouped))
+			}
+			actions = append(actions, leave, back)
+			jump := untyped(k.List.Jump)
+			h.short = append([]key.Binding{up, down, pageDown, jump}, actions...)
+			h.full = [][]key.Binding{
+				{up, down, pageUp, pageDown, home, end, jump},
+				actions,
+			}
+		default:
+			prev, next, recall := untyped(k.History.Prev), untyped(k.History.Next), untyped(k.History.Search)
+			h.short = []key.Binding{submit, prev, recall, leave, back}
+			h.full = [][]key.Binding{{submit, leave, back}, {prev, next, recall}}
+		}
+	case m.state == StateHealth:
+		h.short = []key.Binding{k.Scroll.Down, k.Health.Refresh, k.Health.Back}
+		h.full = [][]key.Binding{{k.Health.Refresh, k.Health.Back}, scroll}
+	case m.state == StateSettings && m.settings.editing:
+		h.short = []key.Binding{k.Settings.Save, k.Settings.Cancel}
+	case m.state == StateSettings:
+		h.short = []key.Binding{k.Settings.Up, k.Settings.Down, k.Settings.Edit, k.Settings.Back}
+	case m.state == StateHelp:
+		h.short = []key.Binding{k.Help.Next, k.Help.Prev, k.Scroll.Down, k.Help.Back}
+		h.full = [][]key.Binding{{k.Help.Next, k.Help.Prev, k.Help.Back}, scroll}
+	default:
+		h.short = []key.Binding{k.Menu.Up, k.Menu.Down, k.Menu.Select, k.Menu.Quit}
+	}
+
+	if h.full == nil {
+		h.full = [][]key.Binding{h.short}
+	}
+	h.full = append(h.full, global)
+	if m.helpOpens() {
+		h.short = append(h.short, k.Global.Help)
+	}
+	return h
+}
+
+// helpOpens reports whether the help key opens the overlay on this screen,
+// rather than being typed into an input
+func (m model) helpOpens() bool {
+	return !m.showIntro && m.state != StateError && !m.jumping && !m.recall.searching && !m.settings.editing
+}
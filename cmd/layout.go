@@ -0,0 +1,172 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	// The layout assumes a classic terminal until the first tea.WindowSizeMsg
+	defaultWidth  = 80
+	defaultHeight = 24
+
+	// Narrower terminals get the compact layout: no padding, blank lines,
+	// menu descriptions or banner
+	compactWidth = 80
+
+	// Boxes stop growing here so lines stay readable on wide terminals
+	maxBoxWidth = 100
+
+	// The intro banner needs this many columns to sit side by side
+	bannerWidth = 115
+)
+
+// resize records the terminal size and re-renders everything laid out
+// for the previous one
+func (m *model) resize(width, height int) {
+	m.width, m.height = width, height
+	m.restyle()
+
+	menuHeight := m.viewHeight() - 12
+	if m.compact() {
+		menuHeight = m.viewHeight() - 6
+	}
+	m.menu.SetSize(min(60, m.boxWidth()-4), min(max(menuHeight, 5), 14))
+
+	m.input.Width = m.inputWidth() - 2 - lipgloss.Width(m.input.Prompt)
+	m.settings.input.Width = max(m.textWidth()-26, 8)
+
+	m.layoutDetail()
+	m.layoutHealth()
+	m.layoutHelp()
+
+	switch {
+	case m.viewingResults && m.groupedView && m.lookup != nil:
+		m.showGrouped()
+	case m.viewingResults && !m.groupedView:
+		m.showResults()
+	}
+}
+
+func (m model) compact() bool {
+	return m.width < compactWidth
+}
+
+// boxWidth is the width of result and settings boxes, borders excluded
+func (m model) boxWidth() int {
+	if m.compact() {
+		return max(m.width-2, 20)
+	}
+	return min(m.width-16, maxBoxWidth)
+}
+
+// textWidth is the room left for text inside a box
+func (m model) textWidth() int {
+	if m.compact() {
+		return m.boxWidth() - 2
+	}
+	return m.boxWidth() - 4
+}
+
+// inputWidth is the width of the search input, borders excluded
+func (m model) inputWidth() int {
+	return m.boxWidth() - 4
+}
+
+// resultsHeight is the number of lines the results box can show below the
+// title and input without pushing the status line off screen
+func (m model) resultsHeight() int {
+	chrome := 12
+	if m.compact() {
+		chrome = 7
+	}
+	return max(m.viewHeight()-chrome, 3)
+}
+
+// pageHeight is the height of a box that fills the screen between the
+// title and the footer
+func (m model) pageHeight() int {
+	chrome := 8
+	if m.compact() {
+		chrome = 4
+	}
+	return max(m.viewHeight()-chrome, 3)
+}
+
+// viewHeight is the terminal height left after the warning banner
+func (m model) viewHeight() int {
+	if m.configWarning != "" {
+		return m.height - lipgloss.Height(m.warningBanner())
+	}
+	return m.height
+}
+
+// box is resultBoxStyle sized for the terminal
+func (m model) box() lipgloss.Style {
+	style := resultBoxStyle.Copy().Width(m.boxWidth())
+	if m.compact() {
+		style = style.Padding(0, 1)
+	}
+	return style
+}
+
+// title is titleStyle without the vertical padding in compact mode
+func (m model) title(text string) string {
+	if m.compact() {
+		return titleStyle.Copy().Padding(0).Render(text)
+	}
+	return titleStyle.Render(text)
+}
+
+// join stacks parts vertically. Empty parts are blank lines, which the
+// compact layout drops.
+func (m model) join(pos lipgloss.Position, parts ...string) string {
+	if m.compact() {
+		var kept []string
+		for _, part := range parts {
+			if part != "" {
+				kept = append(kept, part)
+			}
+		}
+		parts = kept
+	}
+	return lipgloss.JoinVertical(pos, parts...)
+}
+
+// place centres content in the terminal
+func (m model) place(content string) string {
+	return lipgloss.Place(m.width, m.viewHeight(), lipgloss.Center, lipgloss.Center, content)
+}
+
+func (m model) warningBanner() string {
+	return warningBannerStyle.Copy().Width(m.width).Render("⚠️  " + m.configWarning)
+}
+
+// separator is the rule drawn between results
+func separator(width int) string {
+	return resultSeparator.Render(strings.Repeat("┈", width))
+}
+
+// resultsArea is the results box holding as many lines of content as fit
+func (m model) resultsArea(content string) string {
+	box := m.box()
+	height := m.resultsHeight()
+
+	lines := strings.Split(lipgloss.NewStyle().Width(m.textWidth()).Render(content), "\n")
+	if room := max(height-box.GetVerticalPadding(), 1); len(lines) > room {
+		lines = lines[:room]
+	}
+	return box.Height(height).Render(strings.Join(lines, "\n"))
+}
+
+// popup is popupStyle sized for the terminal, borders excluded
+func (m model) popup() lipgloss.Style {
+	style := popupStyle.Copy().
+		Width(min(m.width-10, maxBoxWidth+6)).
+		Height(max(min(m.viewHeight()-3, 30), 10))
+	if m.compact() {
+		style = style.Width(max(m.width-2, 20)).Padding(0, 1)
+	}
+	return style
+}
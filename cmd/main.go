@@ -2,148 +2,32 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"log"
 	"os"
 	"strings"
 	"time"
 
+	"github.com/Nexusrex18/medCli/internal/bookmarks"
 	"github.com/Nexusrex18/medCli/internal/client"
 	"github.com/Nexusrex18/medCli/internal/config"
+	"github.com/Nexusrex18/medCli/internal/history"
 	"github.com/Nexusrex18/medCli/internal/models"
+	"github.com/Nexusrex18/medCli/internal/query"
+	"github.com/Nexusrex18/medCli/internal/repository"
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textinput"
-	// "github.com/charmbracelet/bubbles/viewport"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
-var (
-	logoColor     = lipgloss.Color("#37AC88")
-	medStyle      = lipgloss.NewStyle().Foreground(logoColor).Bold(true)
-	bridgeStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF")).Bold(true)
-	logoStyle     = lipgloss.NewStyle().Foreground(logoColor).Bold(true)
-	block2Style   = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF")).Bold(true)
-	titleStyle    = lipgloss.NewStyle().Bold(true).Foreground(logoColor).Padding(1, 0)
-	selectedStyle = lipgloss.NewStyle().Foreground(logoColor).Bold(true)
-
-	// New UI Theme Colors
-	primaryColor    = lipgloss.Color("#37AC88")
-	secondaryColor  = lipgloss.Color("#2D9C78")
-	accentColor     = lipgloss.Color("#45C9A3")
-	backgroundColor = lipgloss.Color("#0A0F0D")
-	surfaceColor    = lipgloss.Color("#151A17")
-	borderColor     = lipgloss.Color("#2A3A34")
-	textColor       = lipgloss.Color("#E8F3EF")
-	mutedTextColor  = lipgloss.Color("#8A9E96")
-
-	// Input Styles - Fixed BorderRadius issues
-	inputStyle = lipgloss.NewStyle().
-			Border(lipgloss.NormalBorder()).
-			BorderForeground(borderColor).
-			Padding(0, 1).
-			Background(surfaceColor).
-			Foreground(textColor).
-			Height(1).
-			Width(60)
-
-	inputFocusedStyle = lipgloss.NewStyle().
-				Border(lipgloss.NormalBorder()).
-				BorderForeground(primaryColor).
-				Padding(0, 1).
-				Background(surfaceColor).
-				Foreground(textColor).
-				Height(1).
-				Width(60)
-
-	// Result Box Styles - Fixed BorderRadius issues
-	resultBoxStyle = lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(borderColor).
-			Padding(1, 2).
-			Background(surfaceColor).
-			Foreground(textColor).
-			Width(64)
-
-	resultTitleStyle = lipgloss.NewStyle().
-				Foreground(primaryColor).
-				Bold(true)
-
-	resultSubtitleStyle = lipgloss.NewStyle().
-				Foreground(secondaryColor)
-
-	resultTextStyle = lipgloss.NewStyle().
-			Foreground(textColor)
-
-	resultMutedStyle = lipgloss.NewStyle().
-				Foreground(mutedTextColor)
-
-	resultSeparator = lipgloss.NewStyle().
-			Foreground(borderColor).
-			SetString("┈┈┈┈┈┈┈┈┈┈┈┈┈┈┈┈┈┈┈┈┈┈┈┈┈┈┈┈┈┈┈┈┈┈┈┈┈┈┈┈┈┈")
-
-	// Status Bar Style
-	statusStyle = lipgloss.NewStyle().
-			Foreground(mutedTextColor).
-			Italic(true)
-
-	// Menu Styles - Fixed BorderRadius issues
-	menuStyle = lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(borderColor).
-			Padding(1).
-			Background(surfaceColor)
-
-	menuTitleStyle = lipgloss.NewStyle().
-			Foreground(primaryColor).
-			Bold(true).
-			Padding(0, 1)
-
-	menuItemStyle = lipgloss.NewStyle().
-			Foreground(textColor).
-			Padding(0, 1)
-
-	menuSelectedStyle = lipgloss.NewStyle().
-				Foreground(primaryColor).
-				Background(lipgloss.Color("#1A2A24")).
-				Bold(true).
-				Padding(0, 1)
-
-	popupStyle = lipgloss.NewStyle().
-			Border(lipgloss.DoubleBorder()).
-			BorderForeground(primaryColor).
-			Padding(1, 2).
-			Background(surfaceColor).
-			Foreground(textColor).
-			Width(70).
-			Height(20)
-
-	popupTitleStyle = lipgloss.NewStyle().
-			Foreground(primaryColor).
-			Bold(true).
-			Underline(true).
-			Width(66).
-			Align(lipgloss.Center)
-
-	popupSectionStyle = lipgloss.NewStyle().
-				Foreground(secondaryColor).
-				Bold(true)
-
-	popupTextStyle = lipgloss.NewStyle().
-			Foreground(textColor)
-
-	popupButtonStyle = lipgloss.NewStyle().
-				Foreground(textColor).
-				Background(primaryColor).
-				Padding(0, 3).
-				Bold(true)
-
-	popupButtonInactiveStyle = lipgloss.NewStyle().
-					Foreground(mutedTextColor).
-					Border(lipgloss.NormalBorder()).
-					BorderForeground(borderColor).
-					Padding(0, 3)
-)
-
 var block1 = []string{
 	" ██████   ██████ ██████████ ██████████  ",
 	" ░░██████ ██████ ░░███░░░░░█░░███░░░░███ ",
@@ -180,7 +64,6 @@ type model struct {
 	client         *client.TM2Client
 	err            error
 	input          textinput.Model
-	// viewport       viewport.Model
 	results        string
 	startTime      time.Time
 	selectedRecord *models.MedicineRecord
@@ -190,6 +73,43 @@ type model struct {
 	selectedIndex  int
 	lastSearchType string
 	viewingResults bool
+	lastQuery      string
+	groupedView    bool
+	pageOffset     int
+	totalResults   int
+	currentMatches []models.SymptomMatch
+	currentScores  []float64
+	similarSource  *models.MedicineRecord
+	resultsState   AppState
+	// Searches run in the background; searchID identifies the latest one
+	spinner        spinner.Model
+	searching      bool
+	searchID       int
+	cancel         context.CancelFunc
+	dataRule       string // how the CSV file was chosen
+	configWarning  string // why the last config edit was not applied
+	configSettings []config.Setting
+	settings       settingsState
+	lookup         *client.ReverseLookupResult // mappings shown in the grouped view
+	// The current page scrolls inside the results box below a fixed header
+	resultList     viewport.Model
+	resultHeader   string
+	selectOnLoad   int // index to select when the page being fetched arrives
+	jumping        bool
+	jumpInput      textinput.Model
+	detail         viewport.Model // the selected record in the popup
+	health         viewport.Model // the health dashboard stats
+	healthID       int            // identifies the running dashboard refresh loop
+	keys           keyMap
+	help           help.Model
+	showHelp       bool           // the full key help overlay is open
+	helpTopic      int            // index into helpTopics
+	helpPage       viewport.Model // the help browser page
+	history        *history.History
+	recall         recallState
+	bookmarks      *bookmarks.Store
+	bookmarkErr    error // why the last bookmark change was not saved
+	width, height  int                         // terminal size
 }
 
 type AppState int
@@ -204,6 +124,7 @@ const (
 	StateHelp
 	StateError
 	StatePopup
+	StateBookmarks
 )
 
 type item struct {
@@ -214,59 +135,70 @@ func (i item) Title() string       { return i.title }
 func (i item) Description() string { return i.desc }
 func (i item) FilterValue() string { return i.title }
 
-func initialModel() model {
-	cfg, err := config.LoadConfig()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
-		os.Exit(1)
-	}
+func initialModel(loaded *config.Loaded) model {
+	cfg := loaded.Config
 
 	items := []list.Item{
 		item{title: "Search Traditional Medicine Codes", desc: "Search by TM2 or traditional codes"},
 		item{title: "Search by Symptoms", desc: "Find diseases by symptoms"},
+		item{title: "Bookmarks", desc: "Browse your starred mappings"},
 		item{title: "Health Status Dashboard", desc: "View system health"},
 		item{title: "Configuration & Settings", desc: "Manage CLI settings"},
 		item{title: "Help & Documentation", desc: "Access documentation"},
 	}
 
-	// Create a custom delegate for the menu
-	delegate := list.NewDefaultDelegate()
-	delegate.Styles.SelectedTitle = menuSelectedStyle
-	delegate.Styles.SelectedDesc = menuSelectedStyle.Copy().Foreground(secondaryColor)
-	delegate.Styles.NormalTitle = menuItemStyle
-	delegate.Styles.NormalDesc = menuItemStyle.Copy().Foreground(mutedTextColor)
+	// A theme that fails to load falls back to the default with a warning
+	t, themeErr := loadTheme(cfg.Display.Theme)
+	applyTheme(t)
+
+	// So does a keys section that cannot be applied
+	keys, keysErr := loadKeys(cfg.Keys)
 
-	menu := list.New(items, delegate, 60, 14)
+	menu := list.New(items, list.NewDefaultDelegate(), 60, 14)
 	menu.Title = "🌿 TM2 Traditional Medicine CLI"
 	menu.SetShowStatusBar(false)
 	menu.SetShowFilter(false)
+	menu.SetFilteringEnabled(false)
 	menu.SetShowHelp(false)
-	menu.Styles.Title = menuTitleStyle
-	menu.Styles.NoItems = menuItemStyle.Copy().Italic(true)
 
 	tm2Client, err := client.NewTM2Client(cfg)
 	if err != nil {
-		return model{
+		m := model{
 			showIntro: false,
 			menu:      menu,
 			config:    cfg,
 			state:     StateError,
 			err:       err,
+			keys:      keys,
+			help:      help.New(),
 		}
+		m.configWarning = joinWarnings(themeErr, keysErr)
+		m.resize(defaultWidth, defaultHeight)
+		return m
+	}
+
+	// Searching still works when the history cannot be read, it starts empty
+	searches, err := history.Open(history.Path())
+	if err != nil {
+		log.Printf("Could not load search history: %v", err)
+	}
+	marks, marksErr := bookmarks.Open(bookmarks.Path())
+	if marksErr != nil {
+		log.Printf("Could not load bookmarks: %v", marksErr)
 	}
 
 	// Initialize styled text input
 	ti := textinput.New()
 	ti.Placeholder = "Enter code or symptoms..."
-	ti.PlaceholderStyle = lipgloss.NewStyle().Foreground(mutedTextColor)
 	ti.Focus()
 	ti.CharLimit = 156
 	ti.Width = 58
 	ti.Prompt = "❯ "
-	ti.PromptStyle = lipgloss.NewStyle().Foreground(primaryColor)
-	ti.TextStyle = lipgloss.NewStyle().Foreground(textColor)
 
-	return model{
+	sp := spinner.New()
+	sp.Spinner = spinner.Dot
+
+	m := model{
 		showIntro:      true,
 		menu:           menu,
 		config:         cfg,
@@ -280,7 +212,26 @@ func initialModel() model {
 		selectedIndex:  0,
 		lastSearchType: "",
 		viewingResults: false,
+		spinner:        sp,
+		dataRule:       loaded.DataRule,
+		configSettings: loaded.Settings,
+		settings:       settingsState{input: newSettingsInput()},
+		resultList:     viewport.New(0, 0),
+		jumpInput:      newJumpInput(),
+		detail:         viewport.New(0, 0),
+		health:         viewport.New(0, 0),
+		keys:           keys,
+		help:           help.New(),
+		helpPage:       viewport.New(0, 0),
+		history:        searches,
+		recall:         newRecallState(),
+		bookmarks:      marks,
+		bookmarkErr:    marksErr,
 	}
+	m.setKeys(keys)
+	m.configWarning = joinWarnings(themeErr, keysErr)
+	m.resize(defaultWidth, defaultHeight)
+	return m
 }
 
 func (m model) Init() tea.Cmd {
@@ -290,11 +241,49 @@ func (m model) Init() tea.Cmd {
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 
+	// Background search messages are handled whatever screen is showing
+	switch msg := msg.(type) {
+	case searchResultMsg:
+		// Results of a cancelled or superseded search are dropped
+		if m.searching && msg.id == m.searchID {
+			m.applySearchResult(msg)
+		}
+		return m, nil
+	case configChangedMsg:
+		return m.applyConfig(msg)
+	case healthTickMsg:
+		return m.refreshHealth(msg)
+	case tea.WindowSizeMsg:
+		m.resize(msg.Width, msg.Height)
+		return m, nil
+	case spinner.TickMsg:
+		if !m.searching || !m.config.Display.Animations {
+			return m, nil
+		}
+		m.spinner, cmd = m.spinner.Update(msg)
+		m.results = m.searchingView()
+		return m, cmd
+	}
+
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case key.Matches(msg, m.keys.Global.Quit):
+			return m, tea.Quit
+		case m.showHelp:
+			// Any key closes the help overlay
+			m.showHelp = false
+			return m, nil
+		case key.Matches(msg, m.keys.Global.Help) && m.helpOpens():
+			m.showHelp = true
+			return m, nil
+		}
+	}
+
 	switch m.state {
 	case StateError:
 		switch msg := msg.(type) {
 		case tea.KeyMsg:
-			if msg.String() == "q" {
+			if key.Matches(msg, m.keys.Intro.Quit) {
 				return m, tea.Quit
 			}
 		}
@@ -302,10 +291,10 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case StateIntro:
 		switch msg := msg.(type) {
 		case tea.KeyMsg:
-			switch msg.String() {
-			case "q":
+			switch {
+			case key.Matches(msg, m.keys.Intro.Quit):
 				return m, tea.Quit
-			case "enter", " ":
+			case key.Matches(msg, m.keys.Intro.Continue):
 				if m.err != nil {
 					m.state = StateError
 				} else {
@@ -318,30 +307,32 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case StateMenu:
 		switch msg := msg.(type) {
 		case tea.KeyMsg:
-			switch msg.String() {
-			case "q":
+			switch {
+			case key.Matches(msg, m.keys.Menu.Quit):
 				return m, tea.Quit
-			case "enter":
+			case key.Matches(msg, m.keys.Menu.Select):
 				selectedItem := m.menu.SelectedItem().(item)
 				switch selectedItem.title {
 				case "Search Traditional Medicine Codes":
 					m.state = StateSearch
 					m.input.Reset()
+					m.recall.at = -1
 					m.input.Placeholder = "Enter TM2 code..."
 					m.input.Focus()
 				case "Search by Symptoms":
 					m.state = StateSymptoms
 					m.input.Reset()
-					m.input.Placeholder = "Enter symptoms (comma-separated)..."
+					m.recall.at = -1
+					m.input.Placeholder = "Enter symptoms (comma-separated) or a query..."
 					m.input.Focus()
+				case "Bookmarks":
+					return m, m.openBookmarks()
 				case "Health Status Dashboard":
-					m.state = StateHealth
-					m.results = m.getHealthStatus()
-					// m.viewport.SetContent(m.results)
+					return m, m.openHealth()
 				case "Configuration & Settings":
 					m.state = StateSettings
 				case "Help & Documentation":
-					m.state = StateHelp
+					m.openHelp()
 				}
 			}
 		}
@@ -349,167 +340,138 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, cmd
 
 	case StateSearch:
-        switch msg := msg.(type) {
-        case tea.KeyMsg:
-            switch msg.String() {
-            case "q":
-                m.state = StateMenu
-                m.currentRecords = nil
-                m.selectedIndex = 0
-                m.viewingResults = false
-                m.results = ""
-            case "up", "k":
-                if m.viewingResults && len(m.currentRecords) > 0 {
-                    m.selectedIndex--
-                    if m.selectedIndex < 0 {
-                        m.selectedIndex = len(m.currentRecords) - 1
-                    }
-                    m.results = formatSearchResults(m.currentRecords, m.selectedIndex)
+        if m.jumping {
+            return m.updateJump(msg)
+        }
+        if m.recall.searching {
+            return m.updateReverseSearch(msg)
+        }
+        // Keys that type text always go to the input, so queries can hold
+        // any character
+        if msg, ok := msg.(tea.KeyMsg); ok && !typesText(msg) {
+            if cmd, ok := m.updateList(msg); ok {
+                return m, cmd
+            }
+            if m.updateGrouped(msg) {
+                return m, nil
+            }
+            if cmd, ok := m.updateRecall(msg); ok {
+                return m, cmd
+            }
+            switch {
+            case key.Matches(msg, m.keys.Search.Back):
+                m.closeSearch()
+                return m, nil
+            case key.Matches(msg, m.keys.Search.Cancel):
+                if m.searching {
+                    m.cancelSearch()
+                    m.results = resultMutedStyle.Render("Search cancelled")
+                } else {
+                    m.closeSearch()
                 }
-            case "down", "j":
-                if m.viewingResults && len(m.currentRecords) > 0 {
-                    m.selectedIndex++
-                    if m.selectedIndex >= len(m.currentRecords) {
-                        m.selectedIndex = 0
+                return m, nil
+            case key.Matches(msg, m.keys.Search.Grouped):
+                // Toggle between the flat result list and the grouped reverse lookup
+                if m.viewingResults && m.lastSearchType == "code" {
+                    m.groupedView = !m.groupedView
+                    if m.groupedView {
+                        lookup, err := m.client.ReverseLookup(context.Background(), m.lastQuery)
+                        if err != nil {
+                            m.lookup = nil
+                            m.results = fmt.Sprintf("Error: %v", err)
+                        } else {
+                            m.lookup = lookup
+                            m.showGrouped()
+                            m.resultList.GotoTop()
+                        }
+                    } else {
+                        m.showResults()
                     }
-                    m.results = formatSearchResults(m.currentRecords, m.selectedIndex)
                 }
-            case "enter":
+                return m, nil
+            case key.Matches(msg, m.keys.Search.Submit):
                 if m.viewingResults && len(m.currentRecords) > 0 {
                     // Show popup for selected record
-                    m.selectedRecord = &m.currentRecords[m.selectedIndex]
-                    m.showPopup = true
+                    m.openDetail()
+                    m.resultsState = m.state
                     m.state = StatePopup
-                } else {
-                    // Perform new search
-                    query := m.input.Value()
-                    ctx := context.Background()
-                    result, err := m.client.SearchByCode(ctx, query, "both")
-                    if err != nil {
-                        m.results = fmt.Sprintf("Error: %v", err)
-                        m.currentRecords = nil
-                        m.viewingResults = false
-                    } else {
-                        m.currentRecords = result.Records
-                        m.selectedIndex = 0
-                        m.lastSearchType = "code"
-                        m.viewingResults = true
-                        m.results = formatSearchResults(m.currentRecords, m.selectedIndex)
-                    }
+                    return m, nil
                 }
+                return m, m.newSearch()
             }
         }
-        m.input, cmd = m.input.Update(msg)
-        return m, cmd
+        return m.updateInput(msg)
 
 	case StateSymptoms:
-        switch msg := msg.(type) {
-        case tea.KeyMsg:
-            switch msg.String() {
-            case "q":
-                m.state = StateMenu
-                m.currentRecords = nil
-                m.selectedIndex = 0
-                m.viewingResults = false
-                m.results = ""
-            case "up", "k":
-                if m.viewingResults && len(m.currentRecords) > 0 {
-                    m.selectedIndex--
-                    if m.selectedIndex < 0 {
-                        m.selectedIndex = len(m.currentRecords) - 1
-                    }
-                    m.results = formatSymptomResults(m.currentRecords, m.selectedIndex)
-                }
-            case "down", "j":
-                if m.viewingResults && len(m.currentRecords) > 0 {
-                    m.selectedIndex++
-                    if m.selectedIndex >= len(m.currentRecords) {
-                        m.selectedIndex = 0
-                    }
-                    m.results = formatSymptomResults(m.currentRecords, m.selectedIndex)
+        if m.jumping {
+            return m.updateJump(msg)
+        }
+        if m.recall.searching {
+            return m.updateReverseSearch(msg)
+        }
+        if msg, ok := msg.(tea.KeyMsg); ok && !typesText(msg) {
+            if cmd, ok := m.updateList(msg); ok {
+                return m, cmd
+            }
+            if cmd, ok := m.updateRecall(msg); ok {
+                return m, cmd
+            }
+            switch {
+            case key.Matches(msg, m.keys.Search.Back):
+                m.closeSearch()
+                return m, nil
+            case key.Matches(msg, m.keys.Search.Cancel):
+                if m.searching {
+                    m.cancelSearch()
+                    m.results = resultMutedStyle.Render("Search cancelled")
+                } else {
+                    m.closeSearch()
                 }
-            case "enter":
+                return m, nil
+            case key.Matches(msg, m.keys.Search.Submit):
                 if m.viewingResults && len(m.currentRecords) > 0 {
                     // Show popup for selected record
-                    m.selectedRecord = &m.currentRecords[m.selectedIndex]
-                    m.showPopup = true
+                    m.openDetail()
+                    m.resultsState = m.state
                     m.state = StatePopup
-                } else {
-                    // Perform new search
-                    symptoms := strings.Split(m.input.Value(), ",")
-                    for i := range symptoms {
-                        symptoms[i] = strings.TrimSpace(symptoms[i])
-                    }
-                    ctx := context.Background()
-                    result, err := m.client.SearchBySymptoms(ctx, symptoms)
-                    if err != nil {
-                        m.results = fmt.Sprintf("Error: %v", err)
-                        m.currentRecords = nil
-                        m.viewingResults = false
-                    } else {
-                        m.currentRecords = result.Records
-                        m.selectedIndex = 0
-                        m.lastSearchType = "symptoms"
-                        m.viewingResults = true
-                        m.results = formatSymptomResults(m.currentRecords, m.selectedIndex)
-                    }
+                    return m, nil
                 }
+                return m, m.newSearch()
             }
         }
-        m.input, cmd = m.input.Update(msg)
-        return m, cmd
+        return m.updateInput(msg)
 
 	case StateHealth:
-		switch msg := msg.(type) {
-		case tea.KeyMsg:
-			if msg.String() == "q" {
-				m.state = StateMenu
-			}
-			if msg.String() == "r" { // Refresh health status
-				m.results = m.getHealthStatus()
-				// m.viewport.SetContent(m.results)
-			}
-		}
-		// m.viewport, cmd = m.viewport.Update(msg)
-		return m, cmd
+		return m.updateHealth(msg)
+
+	case StateSettings:
+		return m.updateSettings(msg)
 
 	case StatePopup:
-		switch msg := msg.(type) {
-		case tea.KeyMsg:
-			switch msg.String() {
-			case "q", "esc", "enter":
-				m.showPopup = false
-				m.selectedRecord = nil
-				// Return to previous state based on last search type
-				if m.lastSearchType == "code" {
-					m.state = StateSearch
-				} else {
-					m.state = StateSymptoms
-				}
-				// Keep viewingResults as true since we're still viewing results
-			}
-		}
-		return m, nil
+		return m.updateDetail(msg)
 
-	default:
-		switch msg := msg.(type) {
-		case tea.KeyMsg:
-			if msg.String() == "q" {
-				m.state = StateMenu
-			}
-		}
-		m.input, cmd = m.input.Update(msg)
-		// m.viewport, cmd = m.viewport.Update(msg)
-		return m, cmd
+	case StateHelp:
+		return m.updateHelp(msg)
+
+	case StateBookmarks:
+		return m.updateBookmarks(msg)
 	}
 	return m, nil
 }
 
 func (m model) View() string {
+	view := m.view()
+	if m.configWarning == "" {
+		return view
+	}
+	return m.warningBanner() + "\n" + view
+}
+
+func (m model) view() string {
 	if m.state == StateError {
 		errorBox := resultBoxStyle.Copy().
-			BorderForeground(lipgloss.Color("#FF6B6B")).
-			Width(50).
+			BorderForeground(errorColor).
+			Width(min(50, m.boxWidth())).
 			Height(8).
 			Align(lipgloss.Center).
 			Render(
@@ -518,14 +480,18 @@ func (m model) View() string {
 					"",
 					lipgloss.NewStyle().Foreground(textColor).Render(m.err.Error()),
 					"",
-					statusStyle.Render("Press 'q' to quit"),
+					statusStyle.Render(fmt.Sprintf("Press %s to quit", m.keys.Intro.Quit.Help().Key)),
 				),
 			)
-		return lipgloss.Place(80, 20, lipgloss.Center, lipgloss.Center, errorBox)
+		return m.place(errorBox)
 	}
 
 	if m.showIntro {
-		return renderIntro()
+		return m.renderIntro()
+	}
+
+	if m.showHelp {
+		return m.renderHelpOverlay()
 	}
 
 	// Show popup on top of everything if active
@@ -535,114 +501,70 @@ func (m model) View() string {
 
 	switch m.state {
 	case StateMenu:
-		menuContainer := lipgloss.NewStyle().
-			Padding(1, 0).
-			Render(
-				lipgloss.JoinVertical(lipgloss.Center,
-					titleStyle.Render("🌿 TM2 Traditional Medicine CLI"),
-					"",
-					menuStyle.Render(m.menu.View()),
-					"",
-					statusStyle.Render("[↑↓] Navigate • [Enter] Select • [q] Quit"),
-				),
-			)
-		return lipgloss.Place(80, 24, lipgloss.Center, lipgloss.Center, menuContainer)
+		menuContainer := m.join(lipgloss.Center,
+			m.title("🌿 TM2 Traditional Medicine CLI"),
+			"",
+			menuStyle.Render(m.menu.View()),
+			"",
+			m.footer(),
+		)
+		return m.place(menuContainer)
 
 	case StateSearch:
-        inputDisplay := m.input.View()
+        inputDisplay := m.searchInputView()
         if m.input.Focused() {
-            inputDisplay = inputFocusedStyle.Render(inputDisplay)
+            inputDisplay = inputFocusedStyle.Copy().Width(m.inputWidth()).Render(inputDisplay)
         } else {
-            inputDisplay = inputStyle.Render(inputDisplay)
-        }
-
-        statusMsg := "[Enter] Search • [q] Back to Menu"
-        if m.viewingResults {
-            statusMsg = "[↑↓] Navigate • [Enter] View Details • [q] Back to Menu"
+            inputDisplay = inputStyle.Copy().Width(m.inputWidth()).Render(inputDisplay)
         }
 
-        // Create results area with fixed height
-        resultsArea := resultBoxStyle.Copy().Height(14).Render(m.results)
+        // The results box fills the height left by the title and input
+        resultsArea := m.resultsBox()
 
-        content := lipgloss.JoinVertical(lipgloss.Left,
-            titleStyle.Render("🔍 Search Traditional Medicine Codes"),
+        content := m.join(lipgloss.Left,
+            m.title("🔍 Search Traditional Medicine Codes"),
             "",
             inputDisplay,
             "",
             resultsArea, // Use the results directly
             "",
-            statusStyle.Render(statusMsg),
+            m.statusLine(),
         )
-        return lipgloss.Place(80, 24, lipgloss.Center, lipgloss.Center, content)
+        return m.place(content)
 
 	case StateSymptoms:
-        inputDisplay := m.input.View()
+        inputDisplay := m.searchInputView()
         if m.input.Focused() {
-            inputDisplay = inputFocusedStyle.Render(inputDisplay)
+            inputDisplay = inputFocusedStyle.Copy().Width(m.inputWidth()).Render(inputDisplay)
         } else {
-            inputDisplay = inputStyle.Render(inputDisplay)
-        }
-
-        statusMsg := "[Enter] Search • [q] Back to Menu"
-        if m.viewingResults {
-            statusMsg = "[↑↓] Navigate • [Enter] View Details • [q] Back to Menu"
+            inputDisplay = inputStyle.Copy().Width(m.inputWidth()).Render(inputDisplay)
         }
 
-        // Create results area with fixed height
-        resultsArea := resultBoxStyle.Copy().Height(14).Render(m.results)
+        // The results box fills the height left by the title and input
+        resultsArea := m.resultsBox()
 
-        content := lipgloss.JoinVertical(lipgloss.Left,
-            titleStyle.Render("🤒 Search by Symptoms"),
+        content := m.join(lipgloss.Left,
+            m.title("🤒 Search by Symptoms"),
             "",
             inputDisplay,
             "",
             resultsArea, // Use the results directly
             "",
-            statusStyle.Render(statusMsg),
+            m.statusLine(),
         )
-        return lipgloss.Place(80, 24, lipgloss.Center, lipgloss.Center, content)
+        return m.place(content)
         
     case StateHealth:
-		content := lipgloss.JoinVertical(lipgloss.Left,
-			titleStyle.Render("📊 Health Status Dashboard"),
-			"",
-			// m.viewport.View(),
-			"",
-			statusStyle.Render("[r] Refresh • [q] Back to Menu"),
-		)
-		return lipgloss.Place(80, 24, lipgloss.Center, lipgloss.Center, content)
+		return m.viewHealth()
 
 	case StateSettings:
-		settingsBox := resultBoxStyle.Copy().
-			Height(10).
-			Width(50).
-			Align(lipgloss.Center).
-			Render(
-				lipgloss.JoinVertical(lipgloss.Center,
-					"⚙️ Configuration & Settings",
-					"",
-					resultMutedStyle.Render("Settings panel coming soon..."),
-					"",
-					statusStyle.Render("[q] Back to Menu"),
-				),
-			)
-		return lipgloss.Place(80, 24, lipgloss.Center, lipgloss.Center, settingsBox)
+		return m.viewSettings()
 
 	case StateHelp:
-		helpBox := resultBoxStyle.Copy().
-			Height(10).
-			Width(50).
-			Align(lipgloss.Center).
-			Render(
-				lipgloss.JoinVertical(lipgloss.Center,
-					"📖 Help & Documentation",
-					"",
-					resultMutedStyle.Render("Documentation coming soon..."),
-					"",
-					statusStyle.Render("[q] Back to Menu"),
-				),
-			)
-		return lipgloss.Place(80, 24, lipgloss.Center, lipgloss.Center, helpBox)
+		return m.viewHelp()
+
+	case StateBookmarks:
+		return m.viewBookmarks()
 	}
 	return ""
 }
@@ -655,41 +577,289 @@ func (s AppState) String() string {
 		return "Search by Symptoms"
 	case StateHealth:
 		return "Health Status Dashboard"
+	case StateBookmarks:
+		return "Bookmarks"
 	default:
 		return "Unknown State"
 	}
 }
 
-// Update health status to show CSV stats
-func (m *model) getHealthStatus() string {
-	stats := m.client.GetRepoStats()
-	hits, misses, items := m.client.GetCacheStats()
-	uptime := time.Since(m.startTime).Truncate(time.Second)
+// cacheItems describes how full the result cache is
+func cacheItems(stats client.CacheStats) string {
+	switch {
+	case !stats.Enabled:
+		return "disabled"
+	case stats.MaxItems > 0:
+		return fmt.Sprintf("%d / %d", stats.Items, stats.MaxItems)
+	default:
+		return fmt.Sprintf("%d", stats.Items)
+	}
+}
 
-	healthBox := lipgloss.JoinVertical(lipgloss.Left,
-		resultTitleStyle.Render("📊 System Health Status"),
-		"",
-		resultSubtitleStyle.Render("📈 Data Statistics:"),
-		resultTextStyle.Render(fmt.Sprintf("   📚 Total Records: %d", stats["total_records"])),
-		resultTextStyle.Render(fmt.Sprintf("   🏷️  Unique Codes: %d", stats["unique_codes"])),
-		resultTextStyle.Render(fmt.Sprintf("   🔢 Unique TM2 Codes: %d", stats["unique_tm2_codes"])),
-		"",
-		resultSubtitleStyle.Render("⚡ Performance:"),
-		resultTextStyle.Render(fmt.Sprintf("   💾 Cache Hits: %d", hits)),
-		resultTextStyle.Render(fmt.Sprintf("   🔍 Cache Misses: %d", misses)),
-		resultTextStyle.Render(fmt.Sprintf("   📦 Cache Items: %d", items)),
-		resultTextStyle.Render(fmt.Sprintf("   ⏱️  Uptime: %s", uptime)),
-		"",
-		resultMutedStyle.Render("💡 Tip: Press 'r' to refresh stats"),
+// pageSize returns the number of results fetched per page
+func (m model) pageSize() int {
+	if m.config.Display.PageSize > 0 {
+		return m.config.Display.PageSize
+	}
+	return 10
+}
+
+// configChangedMsg is sent when the config file is edited while the TUI runs
+type configChangedMsg struct {
+	loaded *config.Loaded
+	err    error
+}
+
+// applyConfig switches the running interface to an edited configuration.
+// An edit that is invalid or cannot be applied leaves the current
+// settings in place and shows a warning banner instead.
+func (m model) applyConfig(msg configChangedMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.configWarning = "Config change not applied: " + msg.err.Error()
+		return m, nil
+	}
+	if m.client != nil {
+		if err := m.client.Configure(msg.loaded.Config); err != nil {
+			m.configWarning = "Config change not applied: " + err.Error()
+			return m, nil
+		}
+	}
+
+	m.config = msg.loaded.Config
+	m.dataRule = msg.loaded.DataRule
+	m.configSettings = msg.loaded.Settings
+	m.configWarning = ""
+
+	// Reloaded every time so edits to a custom theme file apply as well
+	t, themeErr := loadTheme(m.config.Display.Theme)
+	applyTheme(t)
+	keys, keysErr := loadKeys(m.config.Keys)
+	m.setKeys(keys)
+	m.configWarning = joinWarnings(themeErr, keysErr)
+	m.resize(m.width, m.height)
+
+	switch {
+	case m.state == StateHealth:
+		// Restarted in case auto_refresh was switched on or off
+		return m, m.healthTick()
+	case (m.state == StateSearch || m.state == StateSymptoms) && m.viewingResults && !m.groupedView:
+		// Refetch so a new page size or data set shows straight away
+		m.pageOffset -= m.pageOffset % m.pageSize()
+		return m, m.startSearch()
+	}
+	return m, nil
+}
+
+// searchResultMsg carries the outcome of a background search. id ties it
+// to the search that produced it so stale results can be dropped.
+type searchResultMsg struct {
+	id      int
+	records []models.MedicineRecord
+	matches []models.SymptomMatch
+	scores  []float64
+	total   int
+	err     error
+}
+
+// newSearch runs the query in the search input from its first page and
+// records it in the search history. On the symptoms screen the query
+// language is used when the input calls for it.
+func (m *model) newSearch() tea.Cmd {
+	m.lastSearchType = "code"
+	if m.state == StateSymptoms {
+		m.lastSearchType = "symptoms"
+		if query.LooksLikeQuery(m.input.Value()) {
+			m.lastSearchType = "query"
+		}
+	}
+	m.lastQuery = m.input.Value()
+	m.pageOffset = 0
+	m.groupedView = false
+	m.recall.at = -1
+
+	entry := history.Entry{Time: time.Now(), Type: m.lastSearchType, Query: m.lastQuery}
+	if err := m.history.Add(entry); err != nil {
+		log.Printf("Could not save search history: %v", err)
+	}
+	return m.startSearch()
+}
+
+// startSearch cancels any search in flight and fetches the current page
+// for the last query in the background
+func (m *model) startSearch() tea.Cmd {
+	m.cancelSearch()
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+	m.searchID++
+	m.searching = true
+	m.viewingResults = false
+	m.results = m.searchingView()
+
+	// The command runs outside Update, so it only sees copies of the model state
+	id, tm2Client := m.searchID, m.client
+	searchType, input := m.lastSearchType, m.lastQuery
+	var source models.MedicineRecord
+	if m.similarSource != nil {
+		source = *m.similarSource
+	}
+	var marks []bookmarks.Bookmark
+	if searchType == "bookmarks" {
+		marks = m.bookmarks.Search(input)
+	}
+	opts := repository.SearchOptions{
+		Offset:     m.pageOffset,
+		Limit:      m.pageSize(),
+		SortBy:     repository.SortConfidence,
+		Descending: true,
+	}
+
+	search := func() tea.Msg {
+		msg := searchResultMsg{id: id}
+		switch searchType {
+		case "code":
+			result, err := tm2Client.SearchByCode(ctx, input, "both", opts)
+			if msg.err = err; err == nil {
+				msg.records, msg.total = result.Records, result.Total
+			}
+		case "query":
+			result, err := tm2Client.SearchByQuery(ctx, input, opts)
+			if msg.err = err; err == nil {
+				msg.records, msg.total = result.Records, result.Total
+			}
+		case "bookmarks":
+			msg.records, msg.total = bookmarkedRecords(tm2Client, marks, opts)
+		case "similar":
+			result, err := tm2Client.SimilarTo(ctx, source.TM2Code, source.Code, opts)
+			if msg.err = err; err == nil {
+				msg.total = result.Total
+				for _, scored := range result.Records {
+					msg.records = append(msg.records, scored.Record)
+					msg.scores = append(msg.scores, scored.Score)
+				}
+			}
+		default:
+			symptoms := strings.Split(input, ",")
+			for i := range symptoms {
+				symptoms[i] = strings.TrimSpace(symptoms[i])
+			}
+			result, err := tm2Client.SearchBySymptoms(ctx, symptoms, opts)
+			if msg.err = err; err == nil {
+				msg.records, msg.matches, msg.total = result.Records, result.Matches, result.Total
+			}
+		}
+		return msg
+	}
+
+	if !m.config.Display.Animations {
+		return search
+	}
+	return tea.Batch(search, m.spinner.Tick)
+}
+
+// cancelSearch stops the search in flight, if any
+func (m *model) cancelSearch() {
+	if m.cancel != nil {
+		m.cancel()
+		m.cancel = nil
+	}
+	m.searching = false
+	m.selectOnLoad = 0
+}
+
+// closeSearch leaves a search screen for the menu
+func (m *model) closeSearch() {
+	m.cancelSearch()
+	m.state = StateMenu
+	m.currentRecords = nil
+	m.selectedIndex = 0
+	m.viewingResults = false
+	m.groupedView = false
+	m.results = ""
+}
+
+// updateInput passes msg to the search input, cancelling the search in
+// flight when the input is edited
+func (m model) updateInput(msg tea.Msg) (tea.Model, tea.Cmd) {
+	before := m.input.Value()
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	if m.searching && m.input.Value() != before {
+		m.cancelSearch()
+		m.results = ""
+	}
+	return m, cmd
+}
+
+// searchingView is shown in the results area while a search runs
+func (m model) searchingView() string {
+	return lipgloss.JoinVertical(lipgloss.Left,
+		resultTitleStyle.Render(m.spinner.View()+" Searching..."),
+		resultMutedStyle.Render(fmt.Sprintf("Press %s to cancel", m.keys.Search.Cancel.Help().Key)),
 	)
+}
+
+// applySearchResult shows a finished search and resets the selection
+func (m *model) applySearchResult(msg searchResultMsg) {
+	m.searching = false
+	m.cancel = nil
+
+	if msg.err != nil {
+		m.results = fmt.Sprintf("Error: %v", msg.err)
+		var syntaxErr *query.SyntaxError
+		if errors.As(msg.err, &syntaxErr) {
+			m.results = lipgloss.JoinVertical(lipgloss.Left,
+				resultTitleStyle.Render("⚠️  Invalid query"),
+				resultMutedStyle.Render(syntaxErr.Msg),
+				"",
+				resultTextStyle.Render(syntaxErr.Caret()),
+			)
+		}
+		m.currentRecords = nil
+		m.viewingResults = false
+		return
+	}
+
+	m.currentRecords = msg.records
+	m.currentMatches = msg.matches
+	m.currentScores = msg.scores
+	m.totalResults = msg.total
+	m.selectedIndex = max(min(m.selectOnLoad, len(msg.records)-1), 0)
+	m.selectOnLoad = 0
+	m.viewingResults = true
+	m.showResults()
+
+	// The popup follows to the next or previous page
+	if m.state == StatePopup && len(m.currentRecords) > 0 {
+		m.openDetail()
+	}
+}
+
+// formatResults renders the current page using the formatter for the last search type
+func (m model) formatResults() resultPage {
+	switch m.lastSearchType {
+	case "code":
+		return formatSearchResults(m.currentRecords, m.selectedIndex, m.pageOffset, m.totalResults, m.textWidth())
+	case "similar":
+		return formatSimilarResults(*m.similarSource, m.currentRecords, m.currentScores, m.selectedIndex, m.pageOffset, m.totalResults, m.textWidth())
+	case "bookmarks":
+		return formatBookmarkResults(m.currentRecords, m.selectedIndex, m.pageOffset, m.totalResults, m.textWidth(), m.noBookmarks())
+	}
+	return formatSymptomResults(m.currentRecords, m.currentMatches, m.selectedIndex, m.pageOffset, m.totalResults, m.textWidth())
+}
 
-	return resultBoxStyle.Copy().Height(16).Render(healthBox)
+// pageHeader describes which slice of the total result set is on screen
+func pageHeader(noun string, count, offset, total int) string {
+	if count == total {
+		return fmt.Sprintf("Found %d %s", total, noun)
+	}
+	return fmt.Sprintf("Found %d %s (showing %d-%d)", total, noun, offset+1, offset+count)
 }
 
-func formatSearchResults(records []models.MedicineRecord, selectedIndex int) string {
+func formatSearchResults(records []models.MedicineRecord, selectedIndex, offset, total, width int) resultPage {
 	if len(records) == 0 {
 		noResults := resultBoxStyle.Copy().
 			BorderForeground(mutedTextColor).
+			Width(width-2).
 			Height(6).
 			Align(lipgloss.Center).
 			Render(
@@ -698,62 +868,101 @@ func formatSearchResults(records []models.MedicineRecord, selectedIndex int) str
 					resultMutedStyle.Render("Try a different code or term"),
 				),
 			)
-		return noResults
-	}
-
-	// Limit to first 10 results to prevent overload
-	displayRecords := records
-	if len(records) > 10 {
-		displayRecords = records[:10]
+		return resultPage{header: noResults}
 	}
 
-	var results []string
-	results = append(results,
-		resultTitleStyle.Render(fmt.Sprintf("📋 Found %d results (showing first 10)", len(records))),
-		resultMutedStyle.Render("↑↓ to navigate • Enter to view details • q to back"),
+	page := resultPage{header: lipgloss.JoinVertical(lipgloss.Left,
+		resultTitleStyle.Render("📋 "+pageHeader("results", len(records), offset, total)),
 		"",
-	)
+	)}
 
-	for i, record := range displayRecords {
+	for i, record := range records {
 		// Highlight selected item
 		titleStyle := resultTitleStyle
 		subtitleStyle := resultSubtitleStyle
 		textStyle := resultTextStyle
 		
 		if i == selectedIndex {
-			titleStyle = titleStyle.Copy().Foreground(accentColor).Bold(true)
-			subtitleStyle = subtitleStyle.Copy().Foreground(accentColor)
-			textStyle = textStyle.Copy().Foreground(accentColor)
+			titleStyle = selected(titleStyle).Bold(true)
+			subtitleStyle = selected(subtitleStyle)
+			textStyle = selected(textStyle)
 		}
 
 		resultBox := lipgloss.NewStyle().
 			Padding(0, 0).
 			Render(
 				lipgloss.JoinVertical(lipgloss.Left,
-					titleStyle.Render(fmt.Sprintf("%d. %s", i+1, record.TM2Title)),
+					titleStyle.Render(fmt.Sprintf("%d. %s", offset+i+1, record.TM2Title)),
 					subtitleStyle.Render(fmt.Sprintf("   🏷️  TM2 Code: %s • Traditional: %s", record.TM2Code, record.Code)),
 					subtitleStyle.Render(fmt.Sprintf("   📁 Type: %s • Confidence: %.1f%%", record.Type, record.ConfidenceScore*100)),
 					"",
 					textStyle.Render("   📖 Definition:"),
-					textStyle.Render("   "+wrapText(record.TM2Definition, 56, "   ")),
+					textStyle.Render("   "+wrapText(record.TM2Definition, width-4, "   ")),
+				),
+			)
+
+		page.items = append(page.items, resultBox)
+	}
+
+	return page
+}
+
+// formatGroupedResults renders the reverse lookup for a code, one group per
+// code system, with each mapping labelled by its cardinality
+func formatGroupedResults(lookup *client.ReverseLookupResult, width int) string {
+	if lookup.TM2 == nil && lookup.Traditional == nil {
+		return resultBoxStyle.Copy().
+			BorderForeground(mutedTextColor).
+			Width(width-2).
+			Height(6).
+			Align(lipgloss.Center).
+			Render(
+				lipgloss.JoinVertical(lipgloss.Center,
+					"🔗 No mappings found",
+					resultMutedStyle.Render("Try a different code"),
 				),
 			)
+	}
 
-		results = append(results, resultBox)
+	var results []string
+	for _, group := range []*models.MappingGroup{lookup.TM2, lookup.Traditional} {
+		if group == nil {
+			continue
+		}
+
+		heading := fmt.Sprintf("🔗 TM2 %s ← %d traditional code(s)", group.Code, len(group.Mappings))
+		if group.System == "traditional" {
+			heading = fmt.Sprintf("🔗 %s → %d TM2 code(s)", group.Code, len(group.Mappings))
+		}
+		if len(results) > 0 {
+			results = append(results, separator(width))
+		}
+		results = append(results,
+			resultTitleStyle.Render(heading),
+			resultMutedStyle.Render("   "+group.Title),
+			"",
+		)
 
-		// Add separator between results (except for the last one)
-		if i < len(displayRecords)-1 {
-			results = append(results, resultSeparator.String())
+		for _, mapping := range group.Mappings {
+			target := mapping.Record.Code + "  " + mapping.Record.CodeTitle
+			if group.System == "traditional" {
+				target = mapping.Record.TM2Code + "  " + mapping.Record.TM2Title
+			}
+			results = append(results,
+				resultTextStyle.Render("   • "+target),
+				resultSubtitleStyle.Render(fmt.Sprintf("     %s • Confidence: %.1f%%", mapping.Cardinality, mapping.Record.ConfidenceScore*100)),
+			)
 		}
 	}
 
 	return lipgloss.JoinVertical(lipgloss.Left, results...)
 }
 
-func formatSymptomResults(records []models.MedicineRecord, selectedIndex int) string {
+func formatSymptomResults(records []models.MedicineRecord, matches []models.SymptomMatch, selectedIndex, offset, total, width int) resultPage {
 	if len(records) == 0 {
 		noResults := resultBoxStyle.Copy().
 			BorderForeground(mutedTextColor).
+			Width(width-2).
 			Height(6).
 			Align(lipgloss.Center).
 			Render(
@@ -762,51 +971,206 @@ func formatSymptomResults(records []models.MedicineRecord, selectedIndex int) st
 					resultMutedStyle.Render("Try different symptoms or terms"),
 				),
 			)
-		return noResults
+		return resultPage{header: noResults}
 	}
 
-	// Limit to first 10 results to prevent overload
-	displayRecords := records
-	if len(records) > 10 {
-		displayRecords = records[:10]
-	}
-
-	var results []string
-	results = append(results,
-		resultTitleStyle.Render(fmt.Sprintf("🎯 Found %d matches (showing first 10)", len(records))),
-		resultMutedStyle.Render("↑↓ to navigate • Enter to view details • q to back"),
+	page := resultPage{header: lipgloss.JoinVertical(lipgloss.Left,
+		resultTitleStyle.Render("🎯 "+pageHeader("matches", len(records), offset, total)),
 		"",
-	)
+	)}
 
-	for i, record := range displayRecords {
+	for i, record := range records {
 		// Highlight selected item
 		titleStyle := resultTitleStyle
 		subtitleStyle := resultSubtitleStyle
 		
 		if i == selectedIndex {
-			titleStyle = titleStyle.Copy().Foreground(accentColor).Bold(true)
-			subtitleStyle = subtitleStyle.Copy().Foreground(accentColor)
+			titleStyle = selected(titleStyle).Bold(true)
+			subtitleStyle = selected(subtitleStyle)
+		}
+
+		var match models.SymptomMatch
+		if i < len(matches) {
+			match = matches[i]
+		}
+
+		lines := []string{
+			titleStyle.Render(fmt.Sprintf("%d. ", offset+i+1)) +
+				renderHighlighted(record.TM2Title, match.HighlightsFor("tm2_title"), titleStyle),
+			subtitleStyle.Render(fmt.Sprintf("   🏷️  TM2 Code: %s • Traditional: %s", record.TM2Code, record.Code)),
+			subtitleStyle.Render(fmt.Sprintf("   📁 Type: %s • Confidence: %.1f%%", record.Type, record.ConfidenceScore*100)),
+		}
+		if snippet := matchSnippet(record, match.Highlights, width-8); snippet != "" {
+			lines = append(lines, resultMutedStyle.Render("   📖 ")+snippet)
+		}
+		if via := synonymSummary(match.Terms); via != "" {
+			lines = append(lines, resultMutedStyle.Render("   🔁 "+via))
 		}
 
 		resultBox := lipgloss.NewStyle().
 			Padding(0, 0).
+			Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+
+		page.items = append(page.items, resultBox)
+	}
+
+	return page
+}
+
+// formatSimilarResults lists the concepts closest to source, most similar first
+func formatSimilarResults(source models.MedicineRecord, records []models.MedicineRecord, scores []float64, selectedIndex, offset, total, width int) resultPage {
+	if len(records) == 0 {
+		return resultPage{header: resultBoxStyle.Copy().
+			BorderForeground(mutedTextColor).
+			Width(width-2).
+			Height(6).
+			Align(lipgloss.Center).
 			Render(
-				lipgloss.JoinVertical(lipgloss.Left,
-					titleStyle.Render(fmt.Sprintf("%d. %s", i+1, record.TM2Title)),
-					subtitleStyle.Render(fmt.Sprintf("   🏷️  TM2 Code: %s • Traditional: %s", record.TM2Code, record.Code)),
-					subtitleStyle.Render(fmt.Sprintf("   📁 Type: %s • Confidence: %.1f%%", record.Type, record.ConfidenceScore*100)),
+				lipgloss.JoinVertical(lipgloss.Center,
+					"🧭 No similar records found",
+					resultMutedStyle.Render(source.TM2Code+" shares no terms with other concepts"),
 				),
-			)
+			)}
+	}
+
+	page := resultPage{header: lipgloss.JoinVertical(lipgloss.Left,
+		resultTitleStyle.Render("🧭 "+pageHeader("similar concepts", len(records), offset, total)),
+		resultMutedStyle.Render(fmt.Sprintf("Like %s %s", source.TM2Code, source.TM2Title)),
+		"",
+	)}
+
+	for i, record := range records {
+		titleStyle := resultTitleStyle
+		subtitleStyle := resultSubtitleStyle
+		if i == selectedIndex {
+			titleStyle = selected(titleStyle).Bold(true)
+			subtitleStyle = selected(subtitleStyle)
+		}
+
+		page.items = append(page.items, lipgloss.JoinVertical(lipgloss.Left,
+			titleStyle.Render(fmt.Sprintf("%d. %s", offset+i+1, record.TM2Title)),
+			subtitleStyle.Render(fmt.Sprintf("   🏷️  TM2 Code: %s • Traditional: %s", record.TM2Code, record.Code)),
+			subtitleStyle.Render(fmt.Sprintf("   🧭 Similarity: %.1f%% • Confidence: %.1f%%", scores[i]*100, record.ConfidenceScore*100)),
+		))
+	}
+
+	return page
+}
 
-		results = append(results, resultBox)
+// renderHighlighted renders text with base, emphasising the highlighted
+// byte spans
+func renderHighlighted(text string, highlights []models.Highlight, base lipgloss.Style) string {
+	return markSpans(text, highlights,
+		func(plain string) string { return base.Render(plain) },
+		func(matched string) string { return highlightStyle.Render(matched) },
+	)
+}
 
-		// Add separator between results (except for the last one)
-		if i < len(displayRecords)-1 {
-			results = append(results, resultSeparator.String())
+// markSpans passes the highlighted byte spans of text through match and
+// the text between them through plain
+func markSpans(text string, highlights []models.Highlight, plain, match func(string) string) string {
+	var b strings.Builder
+	last := 0
+	for _, h := range highlights {
+		if h.Start < last || h.End > len(text) {
+			continue
+		}
+		if h.Start > last {
+			b.WriteString(plain(text[last:h.Start]))
 		}
+		b.WriteString(match(text[h.Start:h.End]))
+		last = h.End
+	}
+	if last < len(text) {
+		b.WriteString(plain(text[last:]))
 	}
+	return b.String()
+}
 
-	return lipgloss.JoinVertical(lipgloss.Left, results...)
+// excerpt is part of a field around a match, with the highlights that
+// fall in it shifted to its start
+type excerpt struct {
+	field      string
+	text       string
+	highlights []models.Highlight
+	cutStart   bool // text was cut before the excerpt
+	cutEnd     bool // and after it
+}
+
+// matchExcerpt returns about width bytes around the first match outside
+// the title, so it is clear which text the record matched on. It reports
+// false when every match is in the title.
+func matchExcerpt(record models.MedicineRecord, highlights []models.Highlight, width int) (excerpt, bool) {
+	fields := map[string]string{
+		"code_title":       record.CodeTitle,
+		"tm2_definition":   record.TM2Definition,
+		"code_description": record.Description,
+	}
+
+	for _, h := range highlights {
+		text, ok := fields[h.Field]
+		if !ok {
+			continue
+		}
+
+		// Centre the window on the match and widen it to whole words
+		start := max(h.Start-width/3, 0)
+		for start > 0 && text[start-1] != ' ' {
+			start--
+		}
+		end := min(max(start+width, h.End), len(text))
+		for end < len(text) && text[end] != ' ' {
+			end++
+		}
+
+		var shifted []models.Highlight
+		for _, other := range highlights {
+			if other.Field == h.Field && other.Start >= start && other.End <= end {
+				shifted = append(shifted, models.Highlight{Field: other.Field, Start: other.Start - start, End: other.End - start})
+			}
+		}
+
+		return excerpt{
+			field:      h.Field,
+			text:       text[start:end],
+			highlights: shifted,
+			cutStart:   start > 0,
+			cutEnd:     end < len(text),
+		}, true
+	}
+
+	return excerpt{}, false
+}
+
+// matchSnippet renders the excerpt of matchExcerpt, or returns "" when
+// every match is in the title
+func matchSnippet(record models.MedicineRecord, highlights []models.Highlight, width int) string {
+	ex, ok := matchExcerpt(record, highlights, width)
+	if !ok {
+		return ""
+	}
+	snippet := renderHighlighted(ex.text, ex.highlights, resultMutedStyle)
+	if ex.cutStart {
+		snippet = resultMutedStyle.Render("…") + snippet
+	}
+	if ex.cutEnd {
+		snippet += resultMutedStyle.Render("…")
+	}
+	return snippet
+}
+
+// synonymSummary explains which query terms only matched through a synonym
+func synonymSummary(terms []models.TermMatch) string {
+	var parts []string
+	for _, term := range terms {
+		if term.Synonym != "" {
+			parts = append(parts, fmt.Sprintf("%q via %q", term.Term, term.Synonym))
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "Matched " + strings.Join(parts, ", ")
 }
 
 // Helper function to wrap text
@@ -842,44 +1206,45 @@ func wrapText(text string, width int, prefix string) string {
 	return strings.Join(lines, "\n")
 }
 
-func renderIntro() string {
+func (m model) renderIntro() string {
 	var b strings.Builder
 
-	// Render blocks side by side with proper styling
-	maxLines := max(len(block1), len(block2))
-	for i := 0; i < maxLines; i++ {
-		var line strings.Builder
+	// The banner only fits on large terminals; smaller ones get the name alone
+	if m.width >= bannerWidth && m.viewHeight() >= len(block2)+12 {
+		maxLines := max(len(block1), len(block2))
+		for i := 0; i < maxLines; i++ {
+			var line strings.Builder
+
+			// Add block1 (green)
+			if i < len(block1) {
+				line.WriteString(logoStyle.Render(block1[i]))
+			} else {
+				line.WriteString(strings.Repeat(" ", len(block1[0])))
+			}
 
-		// Add block1 (green)
-		if i < len(block1) {
-			line.WriteString(logoStyle.Render(block1[i]))
-		} else {
-			line.WriteString(strings.Repeat(" ", len(block1[0])))
-		}
+			// Add spacing between blocks
+			line.WriteString("  ")
 
-		// Add spacing between blocks
-		line.WriteString("  ")
+			// Add block2 (white)
+			if i < len(block2) {
+				line.WriteString(block2Style.Render(block2[i]))
+			}
 
-		// Add block2 (white)
-		if i < len(block2) {
-			line.WriteString(block2Style.Render(block2[i]))
+			// Center the entire line
+			b.WriteString(lipgloss.PlaceHorizontal(m.width, lipgloss.Center, line.String()))
+			b.WriteString("\n")
 		}
-
-		// Center the entire line
-		fullLine := line.String()
-		b.WriteString(lipgloss.PlaceHorizontal(120, lipgloss.Center, fullLine))
 		b.WriteString("\n")
 	}
 
 	// Add "Medbridge" text - centered properly
-	b.WriteString("\n")
 	medbridgeText := medStyle.Render("Med") + bridgeStyle.Render("bridge")
-	b.WriteString(lipgloss.PlaceHorizontal(120, lipgloss.Center, medbridgeText))
+	b.WriteString(lipgloss.PlaceHorizontal(m.width, lipgloss.Center, medbridgeText))
 	b.WriteString("\n\n")
 
 	// Styled press enter text
 	pressEnterBox := resultBoxStyle.Copy().
-		Width(40).
+		Width(min(40, m.boxWidth())).
 		Height(5).
 		Align(lipgloss.Center).
 		Render(
@@ -887,48 +1252,13 @@ func renderIntro() string {
 				"🚀 Welcome to MedBridge CLI",
 				resultMutedStyle.Render("Traditional Medicine Terminology"),
 				"",
-				resultTextStyle.Render("Press Enter to continue..."),
+				resultTextStyle.Render(fmt.Sprintf("Press %s to continue...", m.keys.Intro.Continue.Help().Key)),
 			),
 		)
 
-	b.WriteString(lipgloss.PlaceHorizontal(120, lipgloss.Center, pressEnterBox))
-
-	// Use a wider container to prevent cutoff
-	return lipgloss.Place(120, 28, lipgloss.Center, lipgloss.Center, b.String())
-}
-
-func (m model) renderPopup() string {
-	if m.selectedRecord == nil {
-		return ""
-	}
-
-	record := m.selectedRecord
-
-	content := lipgloss.JoinVertical(lipgloss.Left,
-		popupTitleStyle.Render(record.TM2Title),
-		resultMutedStyle.Render(fmt.Sprintf("Item %d of %d", m.selectedIndex+1, len(m.currentRecords))),
-		"",
-		popupSectionStyle.Render("Code Information:"),
-		popupTextStyle.Render(fmt.Sprintf("   TM2 Code: %s", record.TM2Code)),
-		popupTextStyle.Render(fmt.Sprintf("   Traditional Code: %s", record.Code)),
-		popupTextStyle.Render(fmt.Sprintf("   Medicine Type: %s", record.Type)),
-		popupTextStyle.Render(fmt.Sprintf("   Confidence Score: %.1f%%", record.ConfidenceScore*100)),
-		"",
-		popupSectionStyle.Render("TM2 Definition:"),
-		popupTextStyle.Render(wrapText(record.TM2Definition, 66, "")),
-		"",
-		popupSectionStyle.Render("Traditional Description:"),
-		popupTextStyle.Render(wrapText(record.Description, 66, "")),
-		"",
-		popupSectionStyle.Render("Code Title:"),
-		popupTextStyle.Render(record.CodeTitle),
-		"",
-		lipgloss.PlaceHorizontal(66, lipgloss.Center,
-			popupButtonStyle.Render("Press Enter/ESC to close")),
-	)
+	b.WriteString(lipgloss.PlaceHorizontal(m.width, lipgloss.Center, pressEnterBox))
 
-	return lipgloss.Place(80, 24, lipgloss.Center, lipgloss.Center,
-		popupStyle.Render(content))
+	return m.place(b.String())
 }
 
 func max(a, b int) int {
@@ -939,7 +1269,44 @@ func max(a, b int) int {
 }
 
 func main() {
-	p := tea.NewProgram(initialModel(), tea.WithAltScreen())
+	args, err := parseGlobalFlags(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		os.Exit(2)
+	}
+	if len(args) > 0 {
+		os.Exit(runCommand(args))
+	}
+
+	loaded, err := config.Load(configOptions)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+	// Refuse to start on the values config validate would reject
+	if err := reportInvalid(loaded.Config); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	m := initialModel(loaded)
+	if metricsAddr != "" && m.client != nil {
+		if err := serveMetrics(metricsAddr, m.client.Metrics()); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	p := tea.NewProgram(m, tea.WithAltScreen())
+
+	// The TUI owns the terminal from here on, so log lines would corrupt it
+	log.SetOutput(io.Discard)
+	loaded.Watch(func(reloaded *config.Loaded, err error) {
+		p.Send(configChangedMsg{loaded: reloaded, err: err})
+	})
+
 	if _, err := p.Run(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error starting TM2 CLI: %v\n", err)
 		os.Exit(1)
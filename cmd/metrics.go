@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/Nexusrex18/medCli/internal/metrics"
+)
+
+// metricsAddr is the address given with --metrics-addr, empty when the
+// TUI serves no metrics
+var metricsAddr string
+
+// serveMetrics serves registry at /metrics on addr for as long as the TUI
+// runs, so the searches made in it can be scraped. The address is bound
+// before returning so one that is taken is reported at startup.
+func serveMetrics(addr string, registry *metrics.Registry) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to serve metrics: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", registry.Handler())
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := server.Serve(listener); err != nil {
+			log.Printf("Metrics server stopped: %v", err)
+		}
+	}()
+	return nil
+}
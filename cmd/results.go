@@ -0,0 +1,241 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// resultPage is a page of results rendered for the results box: a header
+// that stays in view and one block per record
+type resultPage struct {
+	header string
+	items  []string
+}
+
+func newJumpInput() textinput.Model {
+	ti := textinput.New()
+	ti.Prompt = "Go to result: "
+	ti.CharLimit = 7
+	ti.Width = 8
+	return ti
+}
+
+// listing reports whether the results box shows a selectable result list
+func (m model) listing() bool {
+	return m.viewingResults && !m.groupedView && len(m.currentRecords) > 0
+}
+
+// showResults renders the current page into the result list, scrolled
+// just enough to bring the selected record into view
+func (m *model) showResults() {
+	page := m.formatResults()
+	width := m.textWidth()
+	fit := lipgloss.NewStyle().Width(width)
+
+	var lines []string
+	start, end := 0, 0
+	for i, item := range page.items {
+		if i > 0 {
+			lines = append(lines, separator(width))
+		}
+		if i == m.selectedIndex {
+			start = len(lines)
+		}
+		lines = append(lines, strings.Split(fit.Render(item), "\n")...)
+		if i == m.selectedIndex {
+			end = len(lines)
+		}
+	}
+
+	// Fitted here so a header that wraps is counted at its real height
+	m.resultHeader = fit.Render(page.header)
+	m.resultList.Width = width
+	m.resultList.Height = m.listHeight()
+	m.resultList.SetContent(strings.Join(lines, "\n"))
+
+	switch {
+	case start < m.resultList.YOffset:
+		m.resultList.SetYOffset(start)
+	case end > m.resultList.YOffset+m.resultList.Height:
+		m.resultList.SetYOffset(min(start, end-m.resultList.Height))
+	}
+}
+
+// listHeight is the number of result lines visible below the header and
+// above the position line
+func (m model) listHeight() int {
+	room := m.resultsHeight() - m.box().GetVerticalPadding()
+	return max(room-lipgloss.Height(m.resultHeader)-1, 1)
+}
+
+// listArea is the results box holding the scrolled result list
+func (m model) listArea() string {
+	parts := []string{m.resultHeader}
+	if len(m.currentRecords) > 0 {
+		parts = append(parts, m.resultList.View(), resultMutedStyle.Copy().MaxWidth(m.textWidth()).Render(m.position()))
+	}
+	return m.box().Height(m.resultsHeight()).Render(lipgloss.JoinVertical(lipgloss.Left, parts...))
+}
+
+// position says where the selected record sits in the whole result set
+func (m model) position() string {
+	pages := (m.totalResults + m.pageSize() - 1) / m.pageSize()
+	return fmt.Sprintf("Result %d of %d • Page %d of %d",
+		m.pageOffset+m.selectedIndex+1, m.totalResults, m.pageOffset/m.pageSize()+1, pages) + m.starLabel(m.currentRecords[m.selectedIndex])
+}
+
+// selectResult moves the selection to index in the whole result set,
+// fetching the page that holds it when that is not the current page
+func (m *model) selectResult(index int) tea.Cmd {
+	index = max(min(index, m.totalResults-1), 0)
+	if index >= m.pageOffset && index < m.pageOffset+len(m.currentRecords) {
+		m.selectedIndex = index - m.pageOffset
+		m.showResults()
+		return nil
+	}
+
+	m.pageOffset = index - index%m.pageSize()
+	cmd := m.startSearch()
+	m.selectOnLoad = index - m.pageOffset
+	return cmd
+}
+
+// updateList handles the keys that move through the result list. It
+// reports false for keys it does not use.
+func (m *model) updateList(msg tea.KeyMsg) (tea.Cmd, bool) {
+	if !m.listing() {
+		return nil, false
+	}
+	current := m.pageOffset + m.selectedIndex
+
+	switch {
+	case key.Matches(msg, m.keys.List.Up):
+		return m.selectResult(current - 1), true
+	case key.Matches(msg, m.keys.List.Down):
+		return m.selectResult(current + 1), true
+	case key.Matches(msg, m.keys.List.PageUp):
+		return m.selectResult(current - m.pageSize()), true
+	case key.Matches(msg, m.keys.List.PageDown):
+		return m.selectResult(current + m.pageSize()), true
+	case key.Matches(msg, m.keys.List.Home):
+		return m.selectResult(0), true
+	case key.Matches(msg, m.keys.List.End):
+		return m.selectResult(m.totalResults - 1), true
+	case key.Matches(msg, m.keys.List.Jump):
+		m.jumping = true
+		m.jumpInput.Reset()
+		m.jumpInput.Placeholder = fmt.Sprintf("1-%d", m.totalResults)
+		m.jumpInput.Focus()
+		return textinput.Blink, true
+	case key.Matches(msg, m.keys.List.Star):
+		return m.toggleBookmark(m.currentRecords[m.selectedIndex]), true
+	}
+	return nil, false
+}
+
+// grouped reports whether the results box shows the grouped reverse lookup
+func (m model) grouped() bool {
+	return m.viewingResults && m.groupedView && m.lookup != nil
+}
+
+// showGrouped renders the reverse lookup into the result list, keeping the
+// scroll position. A code can have many mappings, so the list keys scroll
+// through them.
+func (m *model) showGrouped() {
+	width := m.textWidth()
+	m.resultHeader = ""
+	m.resultList.Width = width
+	m.resultList.Height = max(m.resultsHeight()-m.box().GetVerticalPadding()-1, 1)
+	m.resultList.SetContent(lipgloss.NewStyle().Width(width).Render(formatGroupedResults(m.lookup, width)))
+}
+
+// updateGrouped scrolls the grouped view with the list keys. It reports
+// false for keys it does not use.
+func (m *model) updateGrouped(msg tea.KeyMsg) bool {
+	if !m.grouped() {
+		return false
+	}
+
+	switch {
+	case key.Matches(msg, m.keys.List.Up):
+		m.resultList.LineUp(1)
+	case key.Matches(msg, m.keys.List.Down):
+		m.resultList.LineDown(1)
+	case key.Matches(msg, m.keys.List.PageUp):
+		m.resultList.ViewUp()
+	case key.Matches(msg, m.keys.List.PageDown):
+		m.resultList.ViewDown()
+	case key.Matches(msg, m.keys.List.Home):
+		m.resultList.GotoTop()
+	case key.Matches(msg, m.keys.List.End):
+		m.resultList.GotoBottom()
+	default:
+		return false
+	}
+	return true
+}
+
+// groupedArea is the results box holding the scrolled reverse lookup, with
+// the lines in view when they do not all fit
+func (m model) groupedArea() string {
+	parts := []string{m.resultList.View()}
+	if total := m.resultList.TotalLineCount(); total > m.resultList.Height {
+		top := m.resultList.YOffset
+		position := fmt.Sprintf("Lines %d-%d of %d", top+1, min(top+m.resultList.Height, total), total)
+		if !m.resultList.AtBottom() {
+			position += " • more below"
+		}
+		parts = append(parts, resultMutedStyle.Copy().MaxWidth(m.textWidth()).Render(position))
+	}
+	return m.box().Height(m.resultsHeight()).Render(lipgloss.JoinVertical(lipgloss.Left, parts...))
+}
+
+// updateJump reads the number typed after List.Jump and selects that result
+func (m model) updateJump(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case key.Matches(msg, m.keys.Jump.Cancel):
+			m.jumping = false
+			return m, nil
+		case key.Matches(msg, m.keys.Jump.Go):
+			m.jumping = false
+			n, err := strconv.Atoi(strings.TrimSpace(m.jumpInput.Value()))
+			if err != nil || n < 1 {
+				return m, nil
+			}
+			return m, m.selectResult(n - 1)
+		}
+	}
+
+	var cmd tea.Cmd
+	m.jumpInput, cmd = m.jumpInput.Update(msg)
+	return m, cmd
+}
+
+// statusLine is the key help below the results, or the jump prompt while
+// a result number is being typed
+func (m model) statusLine() string {
+	if m.jumping {
+		prompt := m.jumpInput.View() + resultMutedStyle.Render(fmt.Sprintf(" of %d • ", m.totalResults))
+		return prompt + m.keyHints(max(min(m.boxWidth()+2, m.width)-lipgloss.Width(prompt), 0))
+	}
+	return m.footer()
+}
+
+// resultsBox is the result list, or the message shown in its place while
+// searching, after an error or in the grouped view
+func (m model) resultsBox() string {
+	switch {
+	case m.grouped():
+		return m.groupedArea()
+	case m.viewingResults && !m.groupedView:
+		return m.listArea()
+	}
+	return m.resultsArea(m.results)
+}
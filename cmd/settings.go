@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/Nexusrex18/medCli/internal/config"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// settingsState is the Settings screen: a cursor over config.Fields and,
+// while a value is being typed, the input holding it
+type settingsState struct {
+	index   int
+	editing bool
+	input   textinput.Model
+	message string
+	failed  bool
+}
+
+func newSettingsInput() textinput.Model {
+	ti := textinput.New()
+	ti.CharLimit = 256
+	ti.Width = 40
+	ti.Prompt = "❯ "
+	return ti
+}
+
+// updateSettings handles keys on the Settings screen. Booleans toggle in
+// place; other values are typed into the input and saved with Enter.
+func (m model) updateSettings(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	s := &m.settings
+
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if s.editing {
+		if ok {
+			switch {
+			case key.Matches(keyMsg, m.keys.Settings.Cancel):
+				s.editing = false
+				s.message = ""
+				return m, nil
+			case key.Matches(keyMsg, m.keys.Settings.Save):
+				s.editing = false
+				return m.saveSetting(config.Fields[s.index], s.input.Value())
+			}
+		}
+		s.input, cmd = s.input.Update(msg)
+		return m, cmd
+	}
+	if !ok {
+		return m, nil
+	}
+
+	switch {
+	case key.Matches(keyMsg, m.keys.Settings.Back):
+		m.state = StateMenu
+		s.message = ""
+	case key.Matches(keyMsg, m.keys.Settings.Up):
+		s.index = (s.index + len(config.Fields) - 1) % len(config.Fields)
+		s.message = ""
+	case key.Matches(keyMsg, m.keys.Settings.Down):
+		s.index = (s.index + 1) % len(config.Fields)
+		s.message = ""
+	case key.Matches(keyMsg, m.keys.Settings.Edit):
+		field := config.Fields[s.index]
+		current := m.settingValue(field)
+		if field.Kind == config.KindBool {
+			next := "true"
+			if current == "true" {
+				next = "false"
+			}
+			return m.saveSetting(field, next)
+		}
+		s.editing = true
+		s.message = ""
+		s.input.SetValue(current)
+		s.input.CursorEnd()
+		s.input.Focus()
+		return m, textinput.Blink
+	}
+	return m, nil
+}
+
+// saveSetting validates value, writes it to the user config file and
+// applies the resulting configuration straight away
+func (m model) saveSetting(field config.Field, value string) (tea.Model, tea.Cmd) {
+	if err := config.Set(field.Key, value); err != nil {
+		m.settings.message, m.settings.failed = err.Error(), true
+		return m, nil
+	}
+
+	loaded, err := config.Load(configOptions)
+	if err == nil {
+		err = config.Validate(loaded.Config)
+	}
+	updated, cmd := m.applyConfig(configChangedMsg{loaded: loaded, err: err})
+	m = updated.(model)
+
+	m.settings.message, m.settings.failed = fmt.Sprintf("Saved %s to %s", field.Key, config.UserConfigPath()), false
+	if loaded != nil {
+		// Say so when the saved value is hidden by a higher precedence source
+		setting, _ := loaded.Setting(field.Key)
+		switch {
+		case setting.Source == config.SourceEnv || setting.Source == config.SourceFlag:
+			m.settings.message += fmt.Sprintf(", but the %s value takes precedence", setting.Source)
+		case setting.Source == config.SourceFile && absPath(loaded.File) != config.UserConfigPath():
+			m.settings.message += fmt.Sprintf(", but %s is loaded first", absPath(loaded.File))
+		}
+	}
+	return m, cmd
+}
+
+// settingValue is the current value of field as it would be typed
+func (m model) settingValue(field config.Field) string {
+	for _, setting := range m.configSettings {
+		if setting.Key == field.Key {
+			return field.Format(setting.Value)
+		}
+	}
+	return ""
+}
+
+func (m model) viewSettings() string {
+	s := m.settings
+
+	rows := []string{resultTitleStyle.Render("⚙️  Configuration & Settings"), ""}
+	for i, field := range config.Fields {
+		value := m.settingValue(field)
+		if value == "" {
+			value = "(not set)"
+		}
+
+		line := fmt.Sprintf("%-22s %s", field.Key, truncate(value, max(m.textWidth()-25, 8)))
+		switch {
+		case i == s.index && s.editing:
+			rows = append(rows, menuSelectedStyle.Render(fmt.Sprintf("%-22s", field.Key))+" "+s.input.View())
+		case i == s.index:
+			rows = append(rows, menuSelectedStyle.Render(line))
+		default:
+			rows = append(rows, menuItemStyle.Render(line))
+		}
+	}
+
+	field := config.Fields[s.index]
+	rows = append(rows, "", resultMutedStyle.Render(truncate(field.Help, m.textWidth()-2)))
+	if s.message != "" {
+		style := resultSubtitleStyle
+		if s.failed {
+			style = warningBannerStyle.Copy().Width(0)
+		}
+		rows = append(rows, style.Render(truncate(s.message, m.textWidth()-2)))
+	}
+
+	content := m.join(lipgloss.Left,
+		m.box().Render(lipgloss.JoinVertical(lipgloss.Left, rows...)),
+		"",
+		m.footer(),
+	)
+	return m.place(content)
+}
+
+// truncate shortens s to at most width runes, marking the cut with an ellipsis
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	return string(runes[:width-1]) + "…"
+}
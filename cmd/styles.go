@@ -0,0 +1,288 @@
+package main
+
+import (
+	"os"
+
+	"github.com/Nexusrex18/medCli/internal/theme"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// The styles below are rebuilt from the active theme by applyTheme
+var (
+	logoColor     lipgloss.TerminalColor
+	medStyle      lipgloss.Style
+	bridgeStyle   lipgloss.Style
+	logoStyle     lipgloss.Style
+	block2Style   lipgloss.Style
+	titleStyle    lipgloss.Style
+	selectedStyle lipgloss.Style
+
+	// Theme colours
+	primaryColor    lipgloss.TerminalColor
+	secondaryColor  lipgloss.TerminalColor
+	accentColor     lipgloss.TerminalColor
+	backgroundColor lipgloss.TerminalColor
+	surfaceColor    lipgloss.TerminalColor
+	selectionColor  lipgloss.TerminalColor
+	borderColor     lipgloss.TerminalColor
+	textColor       lipgloss.TerminalColor
+	mutedTextColor  lipgloss.TerminalColor
+	errorColor      lipgloss.TerminalColor
+
+	inputStyle          lipgloss.Style
+	inputFocusedStyle   lipgloss.Style
+	resultBoxStyle      lipgloss.Style
+	resultTitleStyle    lipgloss.Style
+	resultSubtitleStyle lipgloss.Style
+	resultTextStyle     lipgloss.Style
+	resultMutedStyle    lipgloss.Style
+	resultSeparator     lipgloss.Style
+	statusStyle         lipgloss.Style
+
+	menuStyle         lipgloss.Style
+	menuTitleStyle    lipgloss.Style
+	menuItemStyle     lipgloss.Style
+	menuSelectedStyle lipgloss.Style
+
+	popupStyle               lipgloss.Style
+	popupTitleStyle          lipgloss.Style
+	popupSectionStyle        lipgloss.Style
+	popupTextStyle           lipgloss.Style
+	popupButtonStyle         lipgloss.Style
+	popupButtonInactiveStyle lipgloss.Style
+
+	// Matched search terms inside result text
+	highlightStyle lipgloss.Style
+
+	// Examples and key names in help pages
+	codeStyle lipgloss.Style
+
+	// Non-fatal problems such as a config edit that could not be applied
+	warningBannerStyle lipgloss.Style
+)
+
+// monochrome is set when colours are switched off with NO_COLOR or the
+// terminal has none. Selection and highlights then use reverse video.
+var monochrome bool
+
+func init() {
+	applyTheme(mustDefaultTheme())
+}
+
+func mustDefaultTheme() *theme.Theme {
+	t, err := theme.Load(theme.Default, "")
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// applyTheme rebuilds every style from t. Models holding copies of styles
+// must call restyle afterwards.
+func applyTheme(t *theme.Theme) {
+	// NO_COLOR (https://no-color.org) turns off colour but not bold or
+	// reverse, which lipgloss would drop along with it in its ASCII profile
+	if os.Getenv("NO_COLOR") != "" {
+		lipgloss.SetColorProfile(termenv.ANSI)
+		monochrome = true
+	} else {
+		monochrome = lipgloss.ColorProfile() == termenv.Ascii
+	}
+
+	color := func(role string) lipgloss.TerminalColor {
+		if monochrome {
+			return lipgloss.NoColor{}
+		}
+		return t.Color(role)
+	}
+
+	primaryColor = color("primary")
+	secondaryColor = color("secondary")
+	accentColor = color("accent")
+	backgroundColor = color("background")
+	surfaceColor = color("surface")
+	selectionColor = color("selection")
+	borderColor = color("border")
+	textColor = color("text")
+	mutedTextColor = color("muted")
+	errorColor = color("error")
+
+	logoColor = primaryColor
+	medStyle = lipgloss.NewStyle().Foreground(logoColor).Bold(true)
+	bridgeStyle = lipgloss.NewStyle().Foreground(textColor).Bold(true)
+	logoStyle = lipgloss.NewStyle().Foreground(logoColor).Bold(true)
+	block2Style = lipgloss.NewStyle().Foreground(textColor).Bold(true)
+	titleStyle = lipgloss.NewStyle().Bold(true).Foreground(logoColor).Padding(1, 0)
+	selectedStyle = lipgloss.NewStyle().Foreground(logoColor).Bold(true)
+
+	inputStyle = lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder()).
+		BorderForeground(borderColor).
+		Padding(0, 1).
+		Background(surfaceColor).
+		Foreground(textColor).
+		Height(1).
+		Width(60)
+
+	inputFocusedStyle = inputStyle.Copy().
+		BorderForeground(primaryColor)
+
+	resultBoxStyle = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(borderColor).
+		Padding(1, 2).
+		Background(surfaceColor).
+		Foreground(textColor).
+		Width(64)
+
+	resultTitleStyle = lipgloss.NewStyle().
+		Foreground(primaryColor).
+		Bold(true)
+
+	resultSubtitleStyle = lipgloss.NewStyle().
+		Foreground(secondaryColor)
+
+	resultTextStyle = lipgloss.NewStyle().
+		Foreground(textColor)
+
+	resultMutedStyle = lipgloss.NewStyle().
+		Foreground(mutedTextColor)
+
+	resultSeparator = lipgloss.NewStyle().
+		Foreground(borderColor)
+
+	statusStyle = lipgloss.NewStyle().
+		Foreground(mutedTextColor).
+		Italic(true)
+
+	menuStyle = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(borderColor).
+		Padding(1).
+		Background(surfaceColor)
+
+	menuTitleStyle = lipgloss.NewStyle().
+		Foreground(primaryColor).
+		Bold(true).
+		Padding(0, 1)
+
+	menuItemStyle = lipgloss.NewStyle().
+		Foreground(textColor).
+		Padding(0, 1)
+
+	menuSelectedStyle = lipgloss.NewStyle().
+		Foreground(primaryColor).
+		Background(selectionColor).
+		Bold(true).
+		Padding(0, 1)
+
+	popupStyle = lipgloss.NewStyle().
+		Border(lipgloss.DoubleBorder()).
+		BorderForeground(primaryColor).
+		Padding(1, 2).
+		Background(surfaceColor).
+		Foreground(textColor).
+		Width(70).
+		Height(20)
+
+	popupTitleStyle = lipgloss.NewStyle().
+		Foreground(primaryColor).
+		Bold(true).
+		Underline(true).
+		Width(66).
+		Align(lipgloss.Center)
+
+	popupSectionStyle = lipgloss.NewStyle().
+		Foreground(secondaryColor).
+		Bold(true)
+
+	popupTextStyle = lipgloss.NewStyle().
+		Foreground(textColor)
+
+	popupButtonStyle = lipgloss.NewStyle().
+		Foreground(textColor).
+		Background(primaryColor).
+		Padding(0, 3).
+		Bold(true)
+
+	popupButtonInactiveStyle = lipgloss.NewStyle().
+		Foreground(mutedTextColor).
+		Border(lipgloss.NormalBorder()).
+		BorderForeground(borderColor).
+		Padding(0, 3)
+
+	highlightStyle = lipgloss.NewStyle().
+		Foreground(backgroundColor).
+		Background(accentColor).
+		Bold(true)
+
+	codeStyle = lipgloss.NewStyle().
+		Foreground(accentColor)
+
+	warningBannerStyle = lipgloss.NewStyle().
+		Foreground(errorColor).
+		Bold(true).
+		Width(80)
+
+	if monochrome {
+		// Without colour the focus and selection must still be visible
+		inputFocusedStyle = inputFocusedStyle.BorderStyle(lipgloss.ThickBorder())
+		menuSelectedStyle = menuSelectedStyle.Reverse(true)
+		highlightStyle = highlightStyle.Reverse(true)
+		popupButtonStyle = popupButtonStyle.Reverse(true)
+	}
+}
+
+// selected is style as drawn for the selected result
+func selected(style lipgloss.Style) lipgloss.Style {
+	style = style.Copy().Foreground(accentColor)
+	if monochrome {
+		style = style.Reverse(true)
+	}
+	return style
+}
+
+// loadTheme returns the configured theme, or the default one together with
+// the reason the configured theme could not be loaded
+func loadTheme(name string) (*theme.Theme, error) {
+	t, err := theme.Load(name, theme.Dir())
+	if err != nil {
+		return mustDefaultTheme(), err
+	}
+	return t, nil
+}
+
+// restyle re-applies the current styles to the components that keep their
+// own copies of them
+func (m *model) restyle() {
+	delegate := list.NewDefaultDelegate()
+	delegate.ShowDescription = !m.compact()
+	delegate.Styles.SelectedTitle = menuSelectedStyle
+	delegate.Styles.SelectedDesc = menuSelectedStyle.Copy().Foreground(secondaryColor)
+	delegate.Styles.NormalTitle = menuItemStyle
+	delegate.Styles.NormalDesc = menuItemStyle.Copy().Foreground(mutedTextColor)
+	m.menu.SetDelegate(delegate)
+	m.menu.Styles.Title = menuTitleStyle
+	m.menu.Styles.NoItems = menuItemStyle.Copy().Italic(true)
+
+	m.input.PlaceholderStyle = lipgloss.NewStyle().Foreground(mutedTextColor)
+	m.input.PromptStyle = lipgloss.NewStyle().Foreground(primaryColor)
+	m.input.TextStyle = lipgloss.NewStyle().Foreground(textColor)
+	m.settings.input.PromptStyle = m.input.PromptStyle
+	m.settings.input.TextStyle = m.input.TextStyle
+	m.jumpInput.PromptStyle = m.input.PromptStyle
+	m.jumpInput.TextStyle = m.input.TextStyle
+	m.jumpInput.PlaceholderStyle = m.input.PlaceholderStyle
+
+	m.spinner.Style = lipgloss.NewStyle().Foreground(primaryColor)
+
+	m.help.Styles.ShortKey = lipgloss.NewStyle().Foreground(secondaryColor)
+	m.help.Styles.ShortDesc = statusStyle
+	m.help.Styles.ShortSeparator = lipgloss.NewStyle().Foreground(borderColor)
+	m.help.Styles.FullKey = m.help.Styles.ShortKey
+	m.help.Styles.FullDesc = popupTextStyle
+	m.help.Styles.FullSeparator = m.help.Styles.ShortSeparator
+	m.help.Styles.Ellipsis = statusStyle
+}
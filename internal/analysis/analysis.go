@@ -0,0 +1,159 @@
+// Package analysis turns free text into comparable terms. The same
+// pipeline is used when indexing records and when reading queries: text
+// is folded with textnorm, split into words, stripped of stop words and
+// stemmed, so "pains in joints" and "joint pain" produce the same terms.
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Nexusrex18/medCli/internal/textnorm"
+)
+
+// DefaultLanguage is used when no language is configured
+const DefaultLanguage = "english"
+
+// Token is one analysed word. Text is the folded word as written and Term
+// the stemmed form used for matching. Start and End are byte offsets of
+// the word in the original text.
+type Token struct {
+	Text  string
+	Term  string
+	Start int
+	End   int
+}
+
+// Analyzer is the analysis pipeline for one language
+type Analyzer struct {
+	language  string
+	stopWords map[string]bool
+	stem      func(string) string
+}
+
+// languages holds the pipeline for each supported language
+var languages = map[string]func() *Analyzer{
+	"english": func() *Analyzer {
+		return &Analyzer{language: "english", stopWords: set(englishStopWords), stem: PorterStem}
+	},
+	// simple folds and tokenizes only, for data that should not be stemmed
+	"simple": func() *Analyzer {
+		return &Analyzer{language: "simple", stopWords: map[string]bool{}, stem: func(s string) string { return s }}
+	},
+}
+
+// New returns the analyzer for language, with extraStopWords added to its
+// stop word list
+func New(language string, extraStopWords ...string) (*Analyzer, error) {
+	if language == "" {
+		language = DefaultLanguage
+	}
+	factory, ok := languages[strings.ToLower(language)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported search language %q (available: %s)", language, strings.Join(Languages(), ", "))
+	}
+
+	a := factory()
+	for _, word := range extraStopWords {
+		if word = textnorm.Fold(strings.TrimSpace(word)); word != "" {
+			a.stopWords[word] = true
+		}
+	}
+	return a, nil
+}
+
+// Default returns the analyzer for DefaultLanguage
+func Default() *Analyzer {
+	return languages[DefaultLanguage]()
+}
+
+// Languages lists the supported language names
+func Languages() []string {
+	names := make([]string, 0, len(languages))
+	for name := range languages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (a *Analyzer) Language() string {
+	return a.language
+}
+
+// Analyze splits text into tokens, dropping stop words and words shorter
+// than two characters
+func (a *Analyzer) Analyze(text string) []Token {
+	folded, offsets := textnorm.FoldMap(text)
+
+	var tokens []Token
+	for start := 0; start < len(folded); {
+		r, size := utf8.DecodeRuneInString(folded[start:])
+		if !isWordRune(r) {
+			start += size
+			continue
+		}
+
+		end := start
+		for end < len(folded) {
+			r, size := utf8.DecodeRuneInString(folded[end:])
+			if !isWordRune(r) {
+				break
+			}
+			end += size
+		}
+
+		word := folded[start:end]
+		if len(word) >= 2 && !a.stopWords[word] {
+			tokens = append(tokens, Token{
+				Text:  word,
+				Term:  a.stem(word),
+				Start: offsets[start],
+				End:   offsets[end],
+			})
+		}
+		start = end
+	}
+
+	return tokens
+}
+
+// Terms returns the matching terms of text
+func (a *Analyzer) Terms(text string) []string {
+	tokens := a.Analyze(text)
+	terms := make([]string, len(tokens))
+	for i, token := range tokens {
+		terms[i] = token.Term
+	}
+	return terms
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func set(words []string) map[string]bool {
+	m := make(map[string]bool, len(words))
+	for _, word := range words {
+		m[word] = true
+	}
+	return m
+}
+
+var englishStopWords = []string{
+	"a", "about", "above", "after", "again", "against", "all", "am", "an", "and",
+	"any", "are", "as", "at", "be", "because", "been", "before", "being", "below",
+	"between", "both", "but", "by", "can", "could", "did", "do", "does", "doing",
+	"down", "due", "during", "each", "few", "for", "from", "further", "had", "has",
+	"have", "having", "he", "her", "here", "hers", "him", "his", "how", "i", "if",
+	"in", "into", "is", "it", "its", "itself", "just", "me", "more", "most", "my",
+	"no", "nor", "not", "of", "off", "on", "once", "only", "or", "other", "our",
+	"out", "over", "own", "same", "she", "should", "so", "some", "such", "than",
+	"that", "the", "their", "them", "then", "there", "these", "they", "this",
+	"those", "through", "to", "too", "under", "until", "up", "very", "was", "we",
+	"were", "what", "when", "where", "which", "while", "who", "whom", "why", "will",
+	"with", "would", "you", "your",
+}
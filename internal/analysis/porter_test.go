@@ -0,0 +1,127 @@
+package analysis
+
+import "testing"
+
+func TestPorterStem(t *testing.T) {
+	// Most cases are the examples of Porter's paper, one or more per rule
+	tests := []struct {
+		word, want string
+	}{
+		// Short and non-ASCII words are left alone
+		{"", ""},
+		{"is", "is"},
+		{"as", "as"},
+		{"covid19", "covid19"},
+		{"jvāra", "jvāra"},
+		{"Pains", "Pains"},
+
+		// Step 1a
+		{"caresses", "caress"},
+		{"ponies", "poni"},
+		{"ties", "ti"},
+		{"caress", "caress"},
+		{"cats", "cat"},
+
+		// Step 1b
+		{"feed", "feed"},
+		{"agreed", "agre"},
+		{"plastered", "plaster"},
+		{"bled", "bled"},
+		{"motoring", "motor"},
+		{"sing", "sing"},
+		{"conflated", "conflat"},
+		{"troubled", "troubl"},
+		{"sized", "size"},
+		{"hopping", "hop"},
+		{"tanned", "tan"},
+		{"falling", "fall"},
+		{"hissing", "hiss"},
+		{"fizzed", "fizz"},
+		{"failing", "fail"},
+		{"filing", "file"},
+
+		// Step 1c
+		{"happy", "happi"},
+		{"sky", "sky"},
+
+		// Step 2
+		{"relational", "relat"},
+		{"conditional", "condit"},
+		{"rational", "ration"},
+		{"valenci", "valenc"},
+		{"hesitanci", "hesit"},
+		{"digitizer", "digit"},
+		{"conformabli", "conform"},
+		{"radicalli", "radic"},
+		{"differentli", "differ"},
+		{"vileli", "vile"},
+		{"analogousli", "analog"},
+		{"vietnamization", "vietnam"},
+		{"predication", "predic"},
+		{"operator", "oper"},
+		{"feudalism", "feudal"},
+		{"decisiveness", "decis"},
+		{"hopefulness", "hope"},
+		{"callousness", "callous"},
+		{"formaliti", "formal"},
+		{"sensitiviti", "sensit"},
+		{"sensibiliti", "sensibl"},
+
+		// Step 3
+		{"triplicate", "triplic"},
+		{"formative", "form"},
+		{"formalize", "formal"},
+		{"electriciti", "electr"},
+		{"electrical", "electr"},
+		{"hopeful", "hope"},
+		{"goodness", "good"},
+
+		// Step 4
+		{"revival", "reviv"},
+		{"allowance", "allow"},
+		{"inference", "infer"},
+		{"airliner", "airlin"},
+		{"gyroscopic", "gyroscop"},
+		{"adjustable", "adjust"},
+		{"defensible", "defens"},
+		{"irritant", "irrit"},
+		{"replacement", "replac"},
+		{"adjustment", "adjust"},
+		{"dependent", "depend"},
+		{"adoption", "adopt"},
+		{"homologou", "homolog"},
+		{"communism", "commun"},
+		{"activate", "activ"},
+		{"angulariti", "angular"},
+		{"homologous", "homolog"},
+		{"effective", "effect"},
+		{"bowdlerize", "bowdler"},
+
+		// Step 5
+		{"probate", "probat"},
+		{"rate", "rate"},
+		{"cease", "ceas"},
+		{"controll", "control"},
+		{"roll", "roll"},
+
+		// Several steps in a row
+		{"generalizations", "gener"},
+		{"oscillators", "oscil"},
+
+		// Symptom words that must meet their singular or base form
+		{"pains", "pain"},
+		{"joints", "joint"},
+		{"fevers", "fever"},
+		{"headaches", "headach"},
+		{"headache", "headach"},
+		{"coughing", "cough"},
+		{"swelling", "swell"},
+		{"swollen", "swollen"},
+	}
+
+	for _, tt := range tests {
+		if got := PorterStem(tt.word); got != tt.want {
+			t.Errorf("PorterStem(%q) = %q, want %q", tt.word, got, tt.want)
+		}
+	}
+}
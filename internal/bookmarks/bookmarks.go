@@ -0,0 +1,268 @@
+// Package bookmarks keeps the mappings a user has starred, keyed by TM2
+// code and traditional code. They are stored as a JSON array in
+// ~/.medCli/bookmarks.json, which is also the format of an export, so a
+// team can share bookmarks by passing the file around.
+package bookmarks
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Nexusrex18/medCli/internal/models"
+	"github.com/Nexusrex18/medCli/internal/textnorm"
+)
+
+// Bookmark is a starred mapping. The titles and type are copied from the
+// record when it is starred so bookmarks can be listed without the data
+// set.
+type Bookmark struct {
+	TM2Code   string    `json:"tm2_code"`
+	Code      string    `json:"code"`
+	TM2Title  string    `json:"tm2_title,omitempty"`
+	CodeTitle string    `json:"code_title,omitempty"`
+	Type      string    `json:"type,omitempty"`
+	Added     time.Time `json:"added"`
+}
+
+// FromRecord bookmarks record, added now
+func FromRecord(record models.MedicineRecord) Bookmark {
+	return Bookmark{
+		TM2Code:   record.TM2Code,
+		Code:      record.Code,
+		TM2Title:  record.TM2Title,
+		CodeTitle: record.CodeTitle,
+		Type:      record.Type,
+		Added:     time.Now(),
+	}
+}
+
+// Record is the part of the mapping kept in the bookmark, for when the
+// data set no longer holds it
+func (b Bookmark) Record() models.MedicineRecord {
+	return models.MedicineRecord{
+		TM2Code:   b.TM2Code,
+		Code:      b.Code,
+		TM2Title:  b.TM2Title,
+		CodeTitle: b.CodeTitle,
+		Type:      b.Type,
+	}
+}
+
+// Is reports whether b is the mapping between tm2Code and code. Codes are
+// compared ignoring case, as the repository looks them up.
+func (b Bookmark) Is(tm2Code, code string) bool {
+	return strings.EqualFold(b.TM2Code, tm2Code) && strings.EqualFold(b.Code, code)
+}
+
+// Path is the bookmarks file of the current user
+func Path() string {
+	return filepath.Join(os.ExpandEnv("$HOME"), ".medCli", "bookmarks.json")
+}
+
+// Store is the bookmarks held in a file, in the order they were added
+type Store struct {
+	path      string
+	bookmarks []Bookmark
+	readErr   error // why the file could not be read; it is then never overwritten
+}
+
+// Open reads the bookmarks stored at path. A missing file holds no
+// bookmarks. When the file cannot be read the returned Store is empty and
+// refuses every change, so the file is not lost.
+func Open(path string) (*Store, error) {
+	s := &Store{path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		s.readErr = fmt.Errorf("failed to read bookmarks: %w", err)
+		return s, s.readErr
+	}
+	bookmarks, err := decode(data)
+	if err != nil {
+		s.readErr = fmt.Errorf("%s: %w", path, err)
+		return s, s.readErr
+	}
+	s.bookmarks = bookmarks
+	return s, nil
+}
+
+// decode parses and checks a bookmarks file or export
+func decode(data []byte) ([]Bookmark, error) {
+	var bookmarks []Bookmark
+	if err := json.Unmarshal(data, &bookmarks); err != nil {
+		return nil, fmt.Errorf("not a bookmarks file: %w", err)
+	}
+	for i, b := range bookmarks {
+		if strings.TrimSpace(b.TM2Code) == "" || strings.TrimSpace(b.Code) == "" {
+			return nil, fmt.Errorf("bookmark %d needs both tm2_code and code", i+1)
+		}
+	}
+	return bookmarks, nil
+}
+
+// Path is the file the bookmarks are stored in
+func (s *Store) Path() string {
+	return s.path
+}
+
+// List returns every bookmark, oldest first
+func (s *Store) List() []Bookmark {
+	return s.bookmarks
+}
+
+// Has reports whether the mapping between tm2Code and code is bookmarked
+func (s *Store) Has(tm2Code, code string) bool {
+	return s.indexOf(tm2Code, code) >= 0
+}
+
+func (s *Store) indexOf(tm2Code, code string) int {
+	for i, b := range s.bookmarks {
+		if b.Is(tm2Code, code) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Search returns the bookmarks whose codes, titles or type contain every
+// word of term, compared as textnorm folds them. An empty term matches
+// every bookmark.
+func (s *Store) Search(term string) []Bookmark {
+	words := strings.Fields(textnorm.Fold(term))
+	var found []Bookmark
+	for _, b := range s.bookmarks {
+		text := textnorm.Fold(strings.Join([]string{b.TM2Code, b.Code, b.TM2Title, b.CodeTitle, b.Type}, " "))
+		matches := true
+		for _, word := range words {
+			if !strings.Contains(text, word) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			found = append(found, b)
+		}
+	}
+	return found
+}
+
+// Toggle bookmarks record, or removes its bookmark when it has one, and
+// reports whether it is bookmarked now
+func (s *Store) Toggle(record models.MedicineRecord) (bool, error) {
+	if i := s.indexOf(record.TM2Code, record.Code); i >= 0 {
+		return false, s.save(append(s.bookmarks[:i:i], s.bookmarks[i+1:]...))
+	}
+	if err := s.save(append(s.bookmarks[:len(s.bookmarks):len(s.bookmarks)], FromRecord(record))); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Remove deletes the bookmark of the mapping between tm2Code and code and
+// reports whether there was one
+func (s *Store) Remove(tm2Code, code string) (bool, error) {
+	i := s.indexOf(tm2Code, code)
+	if i < 0 {
+		return false, nil
+	}
+	if err := s.save(append(s.bookmarks[:i:i], s.bookmarks[i+1:]...)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Export writes every bookmark to w in the format Import reads
+func (s *Store) Export(w io.Writer) error {
+	return encode(w, s.bookmarks)
+}
+
+// Import adds the bookmarks exported to r that are not bookmarked yet and
+// returns how many were added. Nothing is added when r holds an invalid
+// bookmark.
+func (s *Store) Import(r io.Reader) (int, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return 0, err
+	}
+	imported, err := decode(data)
+	if err != nil {
+		return 0, err
+	}
+
+	merged := s.bookmarks[:len(s.bookmarks):len(s.bookmarks)]
+	added := 0
+	for _, b := range imported {
+		if s.Has(b.TM2Code, b.Code) || contains(merged[len(s.bookmarks):], b) {
+			continue
+		}
+		if b.Added.IsZero() {
+			b.Added = time.Now()
+		}
+		merged = append(merged, b)
+		added++
+	}
+	if added == 0 {
+		return 0, nil
+	}
+	if err := s.save(merged); err != nil {
+		return 0, err
+	}
+	return added, nil
+}
+
+// contains reports whether bookmarks holds the mapping of b
+func contains(bookmarks []Bookmark, b Bookmark) bool {
+	for _, other := range bookmarks {
+		if other.Is(b.TM2Code, b.Code) {
+			return true
+		}
+	}
+	return false
+}
+
+func encode(w io.Writer, bookmarks []Bookmark) error {
+	if bookmarks == nil {
+		bookmarks = []Bookmark{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(bookmarks)
+}
+
+// save writes bookmarks to a temporary file and renames it over the old
+// one, so an interrupted write never loses them, then keeps them as the
+// bookmarks of s. s is left as it was when they cannot be written.
+func (s *Store) save(bookmarks []Bookmark) error {
+	if s.readErr != nil {
+		return fmt.Errorf("bookmarks not saved: %w", s.readErr)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create bookmarks directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".bookmarks-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to save bookmarks: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := encode(tmp, bookmarks); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save bookmarks: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save bookmarks: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to save bookmarks: %w", err)
+	}
+	s.bookmarks = bookmarks
+	return nil
+}
@@ -0,0 +1,304 @@
+package bookmarks
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Nexusrex18/medCli/internal/models"
+)
+
+func record(tm2Code, code string) models.MedicineRecord {
+	return models.MedicineRecord{TM2Code: tm2Code, Code: code, TM2Title: "Title of " + tm2Code, CodeTitle: "Title of " + code, Type: "Ayurveda"}
+}
+
+// newStore opens a store in a temporary directory holding records
+func newStore(t *testing.T, records ...models.MedicineRecord) *Store {
+	t.Helper()
+	s, err := Open(filepath.Join(t.TempDir(), "bookmarks.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range records {
+		if _, err := s.Toggle(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s
+}
+
+// keys lists the bookmarks of s as "tm2/code"
+func keys(bookmarks []Bookmark) []string {
+	var ks []string
+	for _, b := range bookmarks {
+		ks = append(ks, b.TM2Code+"/"+b.Code)
+	}
+	return ks
+}
+
+func TestOpen(t *testing.T) {
+	tests := []struct {
+		name    string
+		content *string // nil leaves the file missing
+		keys    []string
+		err     string
+	}{
+		{name: "missing file"},
+		{name: "empty array", content: ptr("[]")},
+		{name: "bookmarks", content: ptr(`[{"tm2_code":"SP00","code":"AY-01"},{"tm2_code":"SP01","code":"UN-02"}]`), keys: []string{"SP00/AY-01", "SP01/UN-02"}},
+		{name: "not JSON", content: ptr("SP00,AY-01"), err: "not a bookmarks file"},
+		{name: "not an array", content: ptr(`{"tm2_code":"SP00"}`), err: "not a bookmarks file"},
+		{name: "missing code", content: ptr(`[{"tm2_code":"SP00","code":"AY-01"},{"tm2_code":"SP01","code":" "}]`), err: "bookmark 2 needs both tm2_code and code"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "bookmarks.json")
+			if tt.content != nil {
+				if err := os.WriteFile(path, []byte(*tt.content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			s, err := Open(path)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("Open() error = %v, want one containing %q", err, tt.err)
+				}
+				// The unreadable file is never overwritten
+				if _, err := s.Toggle(record("SP09", "AY-09")); err == nil {
+					t.Error("Toggle() saved over a file that could not be read")
+				}
+				if data, _ := os.ReadFile(path); string(data) != *tt.content {
+					t.Errorf("the file was changed to %q", data)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Open() failed: %v", err)
+			}
+			if got := keys(s.List()); !reflect.DeepEqual(got, tt.keys) {
+				t.Errorf("List() = %v, want %v", got, tt.keys)
+			}
+		})
+	}
+}
+
+func TestImport(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing []models.MedicineRecord
+		export   string
+		added    int
+		keys     []string
+		err      string
+	}{
+		{
+			name:   "into an empty store",
+			export: `[{"tm2_code":"SP00","code":"AY-01"},{"tm2_code":"SP01","code":"UN-02"}]`,
+			added:  2,
+			keys:   []string{"SP00/AY-01", "SP01/UN-02"},
+		},
+		{
+			name:     "after the existing bookmarks",
+			existing: []models.MedicineRecord{record("SP02", "SI-01")},
+			export:   `[{"tm2_code":"SP00","code":"AY-01"}]`,
+			added:    1,
+			keys:     []string{"SP02/SI-01", "SP00/AY-01"},
+		},
+		{
+			name:     "skips mappings already bookmarked, ignoring case",
+			existing: []models.MedicineRecord{record("SP00", "AY-01")},
+			export:   `[{"tm2_code":"sp00","code":"ay-01"},{"tm2_code":"SP01","code":"UN-02"}]`,
+			added:    1,
+			keys:     []string{"SP00/AY-01", "SP01/UN-02"},
+		},
+		{
+			name:   "skips duplicates within the export",
+			export: `[{"tm2_code":"SP00","code":"AY-01"},{"tm2_code":"SP00","code":"AY-01"}]`,
+			added:  1,
+			keys:   []string{"SP00/AY-01"},
+		},
+		{
+			name:     "nothing new",
+			existing: []models.MedicineRecord{record("SP00", "AY-01")},
+			export:   `[{"tm2_code":"SP00","code":"AY-01"}]`,
+			keys:     []string{"SP00/AY-01"},
+		},
+		{
+			name:     "an empty export",
+			existing: []models.MedicineRecord{record("SP00", "AY-01")},
+			export:   `[]`,
+			keys:     []string{"SP00/AY-01"},
+		},
+		{
+			name:     "an invalid bookmark adds nothing",
+			existing: []models.MedicineRecord{record("SP00", "AY-01")},
+			export:   `[{"tm2_code":"SP01","code":"UN-02"},{"code":"UN-03"}]`,
+			keys:     []string{"SP00/AY-01"},
+			err:      "bookmark 2 needs both tm2_code and code",
+		},
+		{
+			name:   "not JSON",
+			export: `tm2_code,code`,
+			err:    "not a bookmarks file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newStore(t, tt.existing...)
+			added, err := s.Import(strings.NewReader(tt.export))
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("Import() error = %v, want one containing %q", err, tt.err)
+				}
+			} else if err != nil {
+				t.Fatalf("Import() failed: %v", err)
+			}
+			if added != tt.added {
+				t.Errorf("Import() added %d, want %d", added, tt.added)
+			}
+			if got := keys(s.List()); !reflect.DeepEqual(got, tt.keys) {
+				t.Errorf("List() = %v, want %v", got, tt.keys)
+			}
+
+			// What is kept in memory is what was saved
+			reopened, err := Open(s.Path())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := keys(reopened.List()); !reflect.DeepEqual(got, tt.keys) {
+				t.Errorf("the file holds %v, want %v", got, tt.keys)
+			}
+		})
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	s := newStore(t, record("SP00", "AY-01"), record("SP01", "UN-02"), record("SP02", "SI-01"))
+
+	var export bytes.Buffer
+	if err := s.Export(&export); err != nil {
+		t.Fatal(err)
+	}
+
+	// Importing into an empty store gives back the same bookmarks
+	other := newStore(t)
+	added, err := other.Import(bytes.NewReader(export.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var again bytes.Buffer
+	if err := other.Export(&again); err != nil {
+		t.Fatal(err)
+	}
+	if added != 3 || again.String() != export.String() {
+		t.Errorf("imported %d bookmarks exported as\n%s\nwant 3 exported as\n%s", added, again.String(), export.String())
+	}
+
+	// An export of no bookmarks is still an array
+	export.Reset()
+	if err := newStore(t).Export(&export); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(export.String()); got != "[]" {
+		t.Errorf("empty export = %q, want []", got)
+	}
+}
+
+func TestImportSetsAdded(t *testing.T) {
+	s := newStore(t)
+	if _, err := s.Import(strings.NewReader(`[{"tm2_code":"SP00","code":"AY-01"},{"tm2_code":"SP01","code":"UN-02","added":"2025-01-02T03:04:05Z"}]`)); err != nil {
+		t.Fatal(err)
+	}
+	list := s.List()
+	if list[0].Added.IsZero() {
+		t.Error("a bookmark imported without a date was not dated")
+	}
+	if want := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC); !list[1].Added.Equal(want) {
+		t.Errorf("imported date = %v, want %v", list[1].Added, want)
+	}
+}
+
+func TestSave(t *testing.T) {
+	tests := []struct {
+		name    string
+		records []models.MedicineRecord // toggled in turn
+		keys    []string
+	}{
+		{name: "adds", records: []models.MedicineRecord{record("SP00", "AY-01"), record("SP01", "UN-02")}, keys: []string{"SP00/AY-01", "SP01/UN-02"}},
+		{name: "removes", records: []models.MedicineRecord{record("SP00", "AY-01"), record("SP01", "UN-02"), record("SP00", "AY-01")}, keys: []string{"SP01/UN-02"}},
+		{name: "removes the last one", records: []models.MedicineRecord{record("SP00", "AY-01"), record("sp00", "ay-01")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The directory does not exist until the first save
+			dir := filepath.Join(t.TempDir(), ".medCli")
+			s, err := Open(filepath.Join(dir, "bookmarks.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, r := range tt.records {
+				if _, err := s.Toggle(r); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			reopened, err := Open(s.Path())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := keys(reopened.List()); !reflect.DeepEqual(got, tt.keys) {
+				t.Errorf("the file holds %v, want %v", got, tt.keys)
+			}
+
+			// The temporary files written on the way are gone
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 1 || entries[0].Name() != "bookmarks.json" {
+				var names []string
+				for _, e := range entries {
+					names = append(names, e.Name())
+				}
+				t.Errorf("the directory holds %v, want only bookmarks.json", names)
+			}
+		})
+	}
+}
+
+func TestSaveFailureKeepsBookmarks(t *testing.T) {
+	s := newStore(t, record("SP00", "AY-01"))
+
+	// Replacing the file with a directory makes the rename fail
+	if err := os.Remove(s.Path()); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(s.Path(), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Toggle(record("SP01", "UN-02")); err == nil {
+		t.Fatal("Toggle() saved over a directory")
+	}
+	if got := keys(s.List()); !reflect.DeepEqual(got, []string{"SP00/AY-01"}) {
+		t.Errorf("List() = %v after a failed save, want the bookmarks before it", got)
+	}
+
+	// Nothing but the directory is left behind
+	entries, err := os.ReadDir(filepath.Dir(s.Path()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("the failed save left %d entries in the directory", len(entries))
+	}
+}
+
+func ptr(s string) *string { return &s }
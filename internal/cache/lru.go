@@ -0,0 +1,142 @@
+// Package cache provides the bounded result cache used by the client.
+// Entries expire after a fixed TTL, and once the cache is full the least
+// recently used entry is evicted to make room for a new one.
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// LRU is a size-bounded cache with per-entry expiry. A nil *LRU is a
+// disabled cache: Get always misses and Set stores nothing.
+type LRU struct {
+	maxItems  int
+	ttl       time.Duration
+	order     *list.List // most recently used at the front
+	items     map[string]*list.Element
+	evictions int
+	mu        sync.Mutex
+}
+
+type entry struct {
+	key     string
+	value   interface{}
+	expires time.Time
+}
+
+// New returns a cache holding at most maxItems entries that each live for
+// ttl. A maxItems of zero or less leaves the size unbounded and a ttl of
+// zero or less keeps entries until they are evicted.
+func New(maxItems int, ttl time.Duration) *LRU {
+	return &LRU{
+		maxItems: maxItems,
+		ttl:      ttl,
+		order:    list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+// Get returns the value stored under key and marks it as recently used
+func (c *LRU) Get(key string) (interface{}, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	e := element.Value.(*entry)
+	if c.expired(e) {
+		c.remove(element)
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return e.value, true
+}
+
+// Set stores value under key, evicting the least recently used entries
+// when the cache is full
+func (c *LRU) Set(key string, value interface{}) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var expires time.Time
+	if c.ttl > 0 {
+		expires = time.Now().Add(c.ttl)
+	}
+
+	if element, ok := c.items[key]; ok {
+		e := element.Value.(*entry)
+		e.value, e.expires = value, expires
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&entry{key: key, value: value, expires: expires})
+	for c.maxItems > 0 && c.order.Len() > c.maxItems {
+		oldest := c.order.Back()
+		// Expired entries are dropped silently; only live ones count as evictions
+		if !c.expired(oldest.Value.(*entry)) {
+			c.evictions++
+		}
+		c.remove(oldest)
+	}
+}
+
+// Flush removes every entry without counting them as evictions
+func (c *LRU) Flush() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.order.Init()
+	c.items = make(map[string]*list.Element)
+}
+
+// Len returns the number of entries, including any that have expired but
+// not yet been dropped
+func (c *LRU) Len() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// Evictions returns the number of live entries dropped to make room
+func (c *LRU) Evictions() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.evictions
+}
+
+// MaxItems returns the size limit, or zero when the size is unbounded
+func (c *LRU) MaxItems() int {
+	if c == nil || c.maxItems < 0 {
+		return 0
+	}
+	return c.maxItems
+}
+
+func (c *LRU) expired(e *entry) bool {
+	return !e.expires.IsZero() && time.Now().After(e.expires)
+}
+
+func (c *LRU) remove(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.items, element.Value.(*entry).key)
+}
@@ -0,0 +1,196 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+// step is one operation on the cache: "set" or "get" a key, "wait" for
+// the given time or "flush". A get expects found.
+type step struct {
+	op    string
+	key   string
+	found bool
+	wait  time.Duration
+}
+
+func set(key string) step       { return step{op: "set", key: key} }
+func hit(key string) step       { return step{op: "get", key: key, found: true} }
+func miss(key string) step      { return step{op: "get", key: key} }
+func wait(d time.Duration) step { return step{op: "wait", wait: d} }
+func flush() step               { return step{op: "flush"} }
+
+func TestLRU(t *testing.T) {
+	const ttl = 100 * time.Millisecond
+
+	tests := []struct {
+		name      string
+		maxItems  int
+		ttl       time.Duration
+		steps     []step
+		len       int
+		evictions int
+	}{
+		{
+			name:     "keeps entries up to the limit",
+			maxItems: 2,
+			steps:    []step{set("a"), set("b"), hit("a"), hit("b"), miss("c")},
+			len:      2,
+		},
+		{
+			name:      "evicts the least recently set entry",
+			maxItems:  2,
+			steps:     []step{set("a"), set("b"), set("c"), miss("a"), hit("b"), hit("c")},
+			len:       2,
+			evictions: 1,
+		},
+		{
+			name:      "a get makes an entry recently used",
+			maxItems:  2,
+			steps:     []step{set("a"), set("b"), hit("a"), set("c"), hit("a"), miss("b"), hit("c")},
+			len:       2,
+			evictions: 1,
+		},
+		{
+			name:      "setting an entry again makes it recently used",
+			maxItems:  2,
+			steps:     []step{set("a"), set("b"), set("a"), set("c"), hit("a"), miss("b")},
+			len:       2,
+			evictions: 1,
+		},
+		{
+			name:      "a limit of one keeps the last entry",
+			maxItems:  1,
+			steps:     []step{set("a"), set("b"), set("c"), miss("a"), miss("b"), hit("c")},
+			len:       1,
+			evictions: 2,
+		},
+		{
+			name:  "a limit of zero is unbounded",
+			steps: []step{set("a"), set("b"), set("c"), set("d"), hit("a"), hit("d")},
+			len:   4,
+		},
+		{
+			name:     "a negative limit is unbounded",
+			maxItems: -1,
+			steps:    []step{set("a"), set("b"), set("c"), hit("a")},
+			len:      3,
+		},
+		{
+			name:     "entries expire after the TTL",
+			maxItems: 10,
+			ttl:      ttl,
+			steps:    []step{set("a"), hit("a"), wait(ttl + ttl/2), miss("a")},
+			len:      0,
+		},
+		{
+			name:     "a get does not extend the TTL",
+			maxItems: 10,
+			ttl:      ttl,
+			steps:    []step{set("a"), wait(ttl * 3 / 5), hit("a"), wait(ttl * 3 / 5), miss("a")},
+			len:      0,
+		},
+		{
+			name:     "setting an entry again restarts its TTL",
+			maxItems: 10,
+			ttl:      ttl,
+			steps:    []step{set("a"), wait(ttl * 3 / 5), set("a"), wait(ttl * 3 / 5), hit("a")},
+			len:      1,
+		},
+		{
+			name:     "expiry follows the time set, not recent use",
+			maxItems: 10,
+			ttl:      ttl,
+			steps:    []step{set("a"), wait(ttl * 3 / 5), set("b"), hit("a"), wait(ttl * 3 / 5), miss("a"), hit("b")},
+			len:      1,
+		},
+		{
+			name:     "expired entries make room without counting as evictions",
+			maxItems: 1,
+			ttl:      ttl,
+			steps:    []step{set("a"), wait(ttl + ttl/2), set("b"), miss("a"), hit("b")},
+			len:      1,
+		},
+		{
+			name:      "live entries are evicted before they expire",
+			maxItems:  2,
+			ttl:       ttl,
+			steps:     []step{set("a"), set("b"), set("c"), miss("a"), hit("b"), hit("c")},
+			len:       2,
+			evictions: 1,
+		},
+		{
+			name:     "no TTL keeps entries until they are evicted",
+			maxItems: 2,
+			steps:    []step{set("a"), wait(ttl / 2), hit("a")},
+			len:      1,
+		},
+		{
+			name:      "flush removes every entry without counting evictions",
+			maxItems:  2,
+			steps:     []step{set("a"), set("b"), set("c"), flush(), miss("b"), miss("c")},
+			len:       0,
+			evictions: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c := New(tt.maxItems, tt.ttl)
+			for i, s := range tt.steps {
+				switch s.op {
+				case "set":
+					c.Set(s.key, s.key)
+				case "get":
+					value, found := c.Get(s.key)
+					if found != s.found {
+						t.Fatalf("step %d: Get(%q) found = %v, want %v", i, s.key, found, s.found)
+					}
+					if found && value != s.key {
+						t.Fatalf("step %d: Get(%q) = %v", i, s.key, value)
+					}
+				case "wait":
+					time.Sleep(s.wait)
+				case "flush":
+					c.Flush()
+				}
+			}
+			if got := c.Len(); got != tt.len {
+				t.Errorf("Len() = %d, want %d", got, tt.len)
+			}
+			if got := c.Evictions(); got != tt.evictions {
+				t.Errorf("Evictions() = %d, want %d", got, tt.evictions)
+			}
+		})
+	}
+}
+
+func TestMaxItems(t *testing.T) {
+	tests := []struct {
+		maxItems, want int
+	}{
+		{10, 10},
+		{1, 1},
+		{0, 0},
+		{-5, 0},
+	}
+
+	for _, tt := range tests {
+		if got := New(tt.maxItems, time.Minute).MaxItems(); got != tt.want {
+			t.Errorf("New(%d, ...).MaxItems() = %d, want %d", tt.maxItems, got, tt.want)
+		}
+	}
+}
+
+func TestNilLRU(t *testing.T) {
+	var c *LRU
+	c.Set("a", 1)
+	if _, found := c.Get("a"); found {
+		t.Error("a nil cache returned a value")
+	}
+	c.Flush()
+	if c.Len() != 0 || c.Evictions() != 0 || c.MaxItems() != 0 {
+		t.Errorf("a nil cache reports %d items, %d evictions and a limit of %d", c.Len(), c.Evictions(), c.MaxItems())
+	}
+}
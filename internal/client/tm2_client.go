@@ -3,94 +3,456 @@ package client
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/Nexusrex18/medCli/internal/analysis"
+	"github.com/Nexusrex18/medCli/internal/cache"
 	"github.com/Nexusrex18/medCli/internal/config"
+	"github.com/Nexusrex18/medCli/internal/metrics"
 	"github.com/Nexusrex18/medCli/internal/models"
+	"github.com/Nexusrex18/medCli/internal/query"
 	"github.com/Nexusrex18/medCli/internal/repository"
-	"github.com/patrickmn/go-cache"
+	"github.com/Nexusrex18/medCli/internal/synonyms"
+	"github.com/Nexusrex18/medCli/internal/textnorm"
 )
 
+// TM2Client is safe for concurrent use. The repository, the result cache
+// and the metrics registry do their own locking, and the configuration,
+// the cache and its counters are swapped and updated atomically.
 type TM2Client struct {
-	repo   *repository.CSVRepository
-	config *config.Config
-	cache  *cache.Cache
-	hits   int
-	misses int
+	repo      *repository.CSVRepository
+	config    atomic.Pointer[config.Config]
+	cache     atomic.Pointer[cache.LRU] // nil when caching is disabled
+	metrics   *metrics.Registry
+	hits      atomic.Int64
+	misses    atomic.Int64
+	evictions atomic.Int64 // evictions of the caches replaced by Configure
 }
 
+// SearchTypes names the kinds of search the client records metrics for
+var SearchTypes = []string{"code", "symptoms", "query", "similar", "reverse"}
+
+// SearchResult holds one page of matches. Count is the size of the page
+// and Total the number of matches across all pages.
 type SearchResult struct {
 	Records []models.MedicineRecord `json:"records"`
 	Count   int                     `json:"count"`
+	Total   int                     `json:"total"`
+	Offset  int                     `json:"offset"`
+	Limit   int                     `json:"limit"`
 }
 
+// SymptomSearchResult holds one page of symptom matches. Matches lines up
+// with Records and explains which term or synonym matched each record.
 type SymptomSearchResult struct {
 	Records []models.MedicineRecord `json:"records"`
+	Matches []models.SymptomMatch   `json:"matches"`
 	Count   int                     `json:"count"`
+	Total   int                     `json:"total"`
+	Offset  int                     `json:"offset"`
+	Limit   int                     `json:"limit"`
+}
+
+// ReverseLookupResult groups the mappings for a code from both sides.
+// Either group is nil when the code is unknown in that system.
+type ReverseLookupResult struct {
+	TM2         *models.MappingGroup `json:"tm2,omitempty"`
+	Traditional *models.MappingGroup `json:"traditional,omitempty"`
+}
+
+// SimilarResult holds one page of records similar to Source
+type SimilarResult struct {
+	Source  models.MedicineRecord `json:"source"`
+	Records []models.ScoredRecord `json:"records"`
+	Count   int                   `json:"count"`
+	Total   int                   `json:"total"`
+	Offset  int                   `json:"offset"`
+	Limit   int                   `json:"limit"`
+}
+
+// sizer is implemented by every result type so that the number of
+// matches can be recorded, including for a nil result on error
+type sizer interface {
+	size() int
+}
+
+func (r *SearchResult) size() int {
+	if r == nil {
+		return 0
+	}
+	return r.Total
+}
+
+func (r *SymptomSearchResult) size() int {
+	if r == nil {
+		return 0
+	}
+	return r.Total
+}
+
+func (r *SimilarResult) size() int {
+	if r == nil {
+		return 0
+	}
+	return r.Total
+}
+
+func (r *ReverseLookupResult) size() int {
+	if r == nil {
+		return 0
+	}
+	n := 0
+	for _, group := range []*models.MappingGroup{r.TM2, r.Traditional} {
+		if group != nil {
+			n += len(group.Mappings)
+		}
+	}
+	return n
 }
 
 func NewTM2Client(cfg *config.Config) (*TM2Client, error) {
-	// Load CSV data
-	repo, err := repository.NewCSVRepository(cfg.CSV.FilePath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to load CSV data: %w", err)
+	// Configure loads the CSV data and indexes it with the configured
+	// analyzer, all of which counts as the load time
+	start := time.Now()
+	c := &TM2Client{
+		metrics: metrics.New(SearchTypes...),
 	}
+	if err := c.Configure(cfg); err != nil {
+		return nil, err
+	}
+
+	c.metrics.ObserveLoad(time.Since(start))
+	c.metrics.SetCacheSource(func() metrics.CacheSample {
+		stats := c.GetCacheStats()
+		return metrics.CacheSample{
+			Hits:      stats.Hits,
+			Misses:    stats.Misses,
+			Evictions: stats.Evictions,
+			Items:     stats.Items,
+			MaxItems:  stats.MaxItems,
+		}
+	})
+
+	return c, nil
+}
+
+// Configure applies a changed configuration to a running client. Search
+// and cache settings take effect at once, a different data file replaces
+// the loaded data, and cached results are dropped whenever they could be
+// stale. Nothing is changed when cfg cannot be applied.
+func (c *TM2Client) Configure(cfg *config.Config) error {
+	previous := c.config.Load()
 
 	cacheTTL, err := time.ParseDuration(cfg.Cache.TTL)
 	if err != nil {
-		return nil, fmt.Errorf("invalid cache TTL format: %w", err)
+		return fmt.Errorf("invalid cache TTL format: %w", err)
+	}
+
+	var analyzer *analysis.Analyzer
+	var dict *synonyms.Dictionary
+	searchChanged := previous == nil ||
+		previous.Search.Language != cfg.Search.Language ||
+		previous.Search.SynonymsFile != cfg.Search.SynonymsFile ||
+		strings.Join(previous.Search.StopWords, ",") != strings.Join(cfg.Search.StopWords, ",")
+	if searchChanged {
+		if analyzer, err = analysis.New(cfg.Search.Language, cfg.Search.StopWords...); err != nil {
+			return err
+		}
+		if dict, err = synonyms.Load(cfg.Search.SynonymsFile); err != nil {
+			return err
+		}
+	}
+
+	// The data is indexed once, with the new analyzer when there is one
+	dataChanged := previous != nil && previous.CSV.FilePath != cfg.CSV.FilePath
+	switch {
+	case previous == nil:
+		repo, err := repository.NewCSVRepository(cfg.CSV.FilePath, analyzer)
+		if err != nil {
+			return fmt.Errorf("failed to load CSV data: %w", err)
+		}
+		c.repo = repo
+	case dataChanged:
+		start := time.Now()
+		err := c.repo.Reload(cfg.CSV.FilePath, analyzer)
+		c.metrics.ObserveReload(time.Since(start), err)
+		if err != nil {
+			return fmt.Errorf("failed to reload CSV data: %w", err)
+		}
+	case searchChanged:
+		c.repo.SetAnalyzer(analyzer)
+	}
+	if searchChanged {
+		c.repo.SetSynonyms(dict)
 	}
 
-	return &TM2Client{
-		repo:   repo,
-		config: cfg,
-		cache:  cache.New(cacheTTL, 10*time.Minute),
-		hits:   0,
-		misses: 0,
-	}, nil
+	if previous == nil || previous.Cache != cfg.Cache {
+		var results *cache.LRU
+		if cfg.Cache.Enabled {
+			results = cache.New(cfg.Cache.MaxItems, cacheTTL)
+		}
+		// Evictions are counted across every cache the client has had
+		c.evictions.Add(int64(c.cache.Load().Evictions()))
+		c.cache.Store(results)
+	} else if searchChanged || dataChanged {
+		c.cache.Load().Flush()
+	}
+
+	c.config.Store(cfg)
+	return nil
+}
+
+// CacheStats reports how the result cache has been used. MaxItems is zero
+// when the cache size is unbounded.
+type CacheStats struct {
+	Enabled   bool `json:"enabled"`
+	Hits      int  `json:"hits"`
+	Misses    int  `json:"misses"`
+	Evictions int  `json:"evictions"`
+	Items     int  `json:"items"`
+	MaxItems  int  `json:"max_items"`
 }
 
-func (c *TM2Client) SearchByCode(ctx context.Context, code string, searchType string) (*SearchResult, error) {
-	cacheKey := "search:" + code + ":" + searchType
-	if cached, found := c.cache.Get(cacheKey); found {
-		c.hits++
+func (c *TM2Client) SearchByCode(ctx context.Context, code string, searchType string, opts repository.SearchOptions) (result *SearchResult, err error) {
+	defer func(start time.Time) { c.observe("code", start, result, err) }(time.Now())
+
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	cacheKey := "search:" + normalizeCode(code) + ":" + searchType + ":" + optionsKey(opts)
+	if cached, found := c.cache.Load().Get(cacheKey); found {
+		c.hits.Add(1)
 		return cached.(*SearchResult), nil
 	}
-	c.misses++
+	c.misses.Add(1)
 
-	records := c.repo.SearchByCode(code)
+	records, total := c.repo.SearchByCode(code, opts)
 
-	result := &SearchResult{
+	result = &SearchResult{
 		Records: records,
 		Count:   len(records),
+		Total:   total,
+		Offset:  opts.Offset,
+		Limit:   opts.Limit,
 	}
 
-	c.cache.Set(cacheKey, result, cache.DefaultExpiration)
+	c.cache.Load().Set(cacheKey, result)
 	return result, nil
 }
 
-func (c *TM2Client) SearchBySymptoms(ctx context.Context, symptoms []string) (*SymptomSearchResult, error) {
-	cacheKey := "symptoms:" + strings.Join(symptoms, ",")
-	if cached, found := c.cache.Get(cacheKey); found {
-		c.hits++
+func (c *TM2Client) SearchBySymptoms(ctx context.Context, symptoms []string, opts repository.SearchOptions) (result *SymptomSearchResult, err error) {
+	defer func(start time.Time) { c.observe("symptoms", start, result, err) }(time.Now())
+
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	cacheKey := "symptoms:" + symptomsKey(symptoms) + ":" + optionsKey(opts)
+	if cached, found := c.cache.Load().Get(cacheKey); found {
+		c.hits.Add(1)
 		return cached.(*SymptomSearchResult), nil
 	}
-	c.misses++
+	c.misses.Add(1)
+
+	matches, total, err := c.repo.SearchBySymptoms(ctx, symptoms, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]models.MedicineRecord, len(matches))
+	for i, match := range matches {
+		records[i] = match.Record
+	}
+
+	result = &SymptomSearchResult{
+		Records: records,
+		Matches: matches,
+		Count:   len(records),
+		Total:   total,
+		Offset:  opts.Offset,
+		Limit:   opts.Limit,
+	}
+
+	c.cache.Load().Set(cacheKey, result)
+	return result, nil
+}
+
+// SearchByQuery parses input with the query language and returns one page
+// of matching records. Parse failures are returned as *query.SyntaxError.
+func (c *TM2Client) SearchByQuery(ctx context.Context, input string, opts repository.SearchOptions) (result *SearchResult, err error) {
+	defer func(start time.Time) { c.observe("query", start, result, err) }(time.Now())
+
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	cacheKey := "query:" + strings.TrimSpace(input) + ":" + optionsKey(opts)
+	if cached, found := c.cache.Load().Get(cacheKey); found {
+		c.hits.Add(1)
+		return cached.(*SearchResult), nil
+	}
+	c.misses.Add(1)
+
+	q, err := query.Parse(input)
+	if err != nil {
+		return nil, err
+	}
 
-	records := c.repo.SearchBySymptoms(symptoms)
+	records, total, err := c.repo.SearchByQuery(ctx, q, opts)
+	if err != nil {
+		return nil, err
+	}
 
-	result := &SymptomSearchResult{
+	result = &SearchResult{
 		Records: records,
 		Count:   len(records),
+		Total:   total,
+		Offset:  opts.Offset,
+		Limit:   opts.Limit,
+	}
+
+	c.cache.Load().Set(cacheKey, result)
+	return result, nil
+}
+
+// SimilarTo returns the TM2 concepts whose text is closest to the record
+// identified by tm2Code and code
+func (c *TM2Client) SimilarTo(ctx context.Context, tm2Code, code string, opts repository.SearchOptions) (result *SimilarResult, err error) {
+	defer func(start time.Time) { c.observe("similar", start, result, err) }(time.Now())
+
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	cacheKey := "similar:" + normalizeCode(tm2Code) + ":" + normalizeCode(code) + ":" + optionsKey(opts)
+	if cached, found := c.cache.Load().Get(cacheKey); found {
+		c.hits.Add(1)
+		return cached.(*SimilarResult), nil
+	}
+	c.misses.Add(1)
+
+	source, ok := c.repo.FindRecord(tm2Code, code)
+	if !ok {
+		return nil, fmt.Errorf("no record found for %s / %s", tm2Code, code)
+	}
+	// The data can be reloaded between the two lookups
+	records, total, ok := c.repo.SimilarTo(tm2Code, code, opts)
+	if !ok {
+		return nil, fmt.Errorf("no record found for %s / %s", tm2Code, code)
+	}
+
+	result = &SimilarResult{
+		Source:  source,
+		Records: records,
+		Count:   len(records),
+		Total:   total,
+		Offset:  opts.Offset,
+		Limit:   opts.Limit,
+	}
+
+	c.cache.Load().Set(cacheKey, result)
+	return result, nil
+}
+
+// SimilarByCode resolves a TM2 or traditional code to its highest
+// confidence mapping and returns the records similar to it
+func (c *TM2Client) SimilarByCode(ctx context.Context, code string, opts repository.SearchOptions) (*SimilarResult, error) {
+	best, _ := c.repo.SearchByCode(code, repository.SearchOptions{Limit: 1, SortBy: repository.SortConfidence, Descending: true})
+	if len(best) == 0 {
+		return nil, fmt.Errorf("no record found for code %q", code)
+	}
+	return c.SimilarTo(ctx, best[0].TM2Code, best[0].Code, opts)
+}
+
+// FindRecord returns the mapping between tm2Code and code
+func (c *TM2Client) FindRecord(tm2Code, code string) (models.MedicineRecord, bool) {
+	return c.repo.FindRecord(tm2Code, code)
+}
+
+func (c *TM2Client) ReverseLookup(ctx context.Context, code string) (result *ReverseLookupResult, err error) {
+	defer func(start time.Time) { c.observe("reverse", start, result, err) }(time.Now())
+
+	cacheKey := "reverse:" + normalizeCode(code)
+	if cached, found := c.cache.Load().Get(cacheKey); found {
+		c.hits.Add(1)
+		return cached.(*ReverseLookupResult), nil
+	}
+	c.misses.Add(1)
+
+	result = &ReverseLookupResult{
+		TM2:         c.repo.GroupByTM2Code(code),
+		Traditional: c.repo.GroupByCode(code),
 	}
 
-	c.cache.Set(cacheKey, result, cache.DefaultExpiration)
+	c.cache.Load().Set(cacheKey, result)
 	return result, nil
 }
 
-func (c *TM2Client) GetCacheStats() (hits, misses, items int) {
-	return c.hits, c.misses, c.cache.ItemCount()
+// normalizeCode makes code lookups that differ only in case or
+// surrounding space share a cache entry, as the repository ignores both
+func normalizeCode(code string) string {
+	return strings.ToLower(strings.TrimSpace(code))
+}
+
+// symptomsKey folds, sorts and dedupes symptoms so that "Fever, cough"
+// and "cough,fever" share a cache entry. Symptoms are ANDed together, so
+// their order and repetition never change the result.
+func symptomsKey(symptoms []string) string {
+	seen := make(map[string]bool, len(symptoms))
+	var folded []string
+	for _, symptom := range symptoms {
+		symptom = strings.Join(strings.Fields(textnorm.Fold(symptom)), " ")
+		if symptom != "" && !seen[symptom] {
+			seen[symptom] = true
+			folded = append(folded, symptom)
+		}
+	}
+	sort.Strings(folded)
+	return strings.Join(folded, ",")
+}
+
+// optionsKey folds the paging options into a cache key suffix
+func optionsKey(opts repository.SearchOptions) string {
+	return fmt.Sprintf("%d:%d:%s:%t", opts.Offset, opts.Limit, opts.SortBy, opts.Descending)
+}
+
+// observe records a finished search in the metrics registry
+func (c *TM2Client) observe(searchType string, start time.Time, result sizer, err error) {
+	c.metrics.ObserveSearch(searchType, result.size(), time.Since(start), err)
+}
+
+// Reload re-reads the dataset from the configured CSV file and drops every
+// cached result. The current data is kept if the file cannot be loaded.
+func (c *TM2Client) Reload() error {
+	start := time.Now()
+	err := c.repo.Reload(c.config.Load().CSV.FilePath, nil)
+	c.metrics.ObserveReload(time.Since(start), err)
+	if err != nil {
+		return fmt.Errorf("failed to reload CSV data: %w", err)
+	}
+	c.cache.Load().Flush()
+	return nil
+}
+
+// Metrics returns the registry recording this client's activity
+func (c *TM2Client) Metrics() *metrics.Registry {
+	return c.metrics
+}
+
+func (c *TM2Client) GetCacheStats() CacheStats {
+	results := c.cache.Load()
+	return CacheStats{
+		Enabled:   results != nil,
+		Hits:      int(c.hits.Load()),
+		Misses:    int(c.misses.Load()),
+		Evictions: int(c.evictions.Load()) + results.Evictions(),
+		Items:     results.Len(),
+		MaxItems:  results.MaxItems(),
+	}
 }
 
 func (c *TM2Client) GetRepoStats() map[string]int {
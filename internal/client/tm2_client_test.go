@@ -0,0 +1,147 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Nexusrex18/medCli/internal/config"
+	"github.com/Nexusrex18/medCli/internal/repository"
+)
+
+func testConfig(maxItems int, stopWords ...string) *config.Config {
+	return &config.Config{
+		CSV:    config.CSVConfig{FilePath: "testdata/records.csv"},
+		Cache:  config.CacheConfig{Enabled: true, TTL: "1h", MaxItems: maxItems},
+		Search: config.SearchConfig{Language: "english", StopWords: stopWords},
+	}
+}
+
+// TestConcurrentUse shares one client between goroutines that search,
+// read the cache stats and reconfigure it at the same time. Run it with
+// -race to check the client's synchronisation.
+func TestConcurrentUse(t *testing.T) {
+	c, err := NewTM2Client(testConfig(1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	codes := map[string]int{"SP00": 3, "sp01": 2, "AY-01": 1, " un-02 ": 1, "missing": 0}
+	symptoms := map[string]int{"fever": 3, "cough, phlegm": 2, "joint pains": 1, "fever, cough": 0}
+
+	const goroutines, rounds = 16, 50
+	var searches, failures sync.WaitGroup
+	errs := make(chan error, goroutines*rounds)
+	calls := make(chan int, goroutines)
+
+	for g := 0; g < goroutines; g++ {
+		searches.Add(1)
+		go func(g int) {
+			defer searches.Done()
+			n := 0
+			for i := 0; i < rounds; i++ {
+				// Small page sizes spread the searches over many cache keys
+				opts := repository.SearchOptions{Limit: 1 + (g+i)%3, SortBy: repository.SortConfidence, Descending: true}
+
+				for code, want := range codes {
+					result, err := c.SearchByCode(context.Background(), code, "code", opts)
+					n++
+					if err != nil {
+						errs <- err
+					} else if result.Total != want {
+						errs <- fmt.Errorf("SearchByCode(%q) found %d records, want %d", code, result.Total, want)
+					}
+				}
+				for input, want := range symptoms {
+					result, err := c.SearchBySymptoms(context.Background(), strings.Split(input, ","), opts)
+					n++
+					if err != nil {
+						errs <- err
+					} else if result.Total != want {
+						errs <- fmt.Errorf("SearchBySymptoms(%q) found %d records, want %d", input, result.Total, want)
+					}
+				}
+
+				if stats := c.GetCacheStats(); stats.Hits < 0 || stats.Misses < 0 || stats.Items < 0 {
+					errs <- fmt.Errorf("impossible cache stats %+v", stats)
+				}
+
+				// Swap the cache for a tiny one and back, and reanalyse with
+				// a stop word that changes no result
+				var cfg *config.Config
+				switch i % 10 {
+				case 3:
+					cfg = testConfig(2)
+				case 6:
+					cfg = testConfig(1000, "persistent")
+				case 9:
+					cfg = testConfig(1000)
+				}
+				if cfg != nil {
+					if err := c.Configure(cfg); err != nil {
+						errs <- err
+					}
+				}
+			}
+			calls <- n
+		}(g)
+	}
+
+	failures.Add(1)
+	var failed []error
+	go func() {
+		defer failures.Done()
+		for err := range errs {
+			failed = append(failed, err)
+		}
+	}()
+
+	searches.Wait()
+	close(errs)
+	close(calls)
+	failures.Wait()
+
+	for i, err := range failed {
+		if i == 10 {
+			t.Errorf("... and %d more", len(failed)-i)
+			break
+		}
+		t.Error(err)
+	}
+
+	total := 0
+	for n := range calls {
+		total += n
+	}
+	// Every search is counted once, as a hit or as a miss
+	if stats := c.GetCacheStats(); stats.Hits+stats.Misses != total {
+		t.Errorf("cache counted %d hits and %d misses for %d searches", stats.Hits, stats.Misses, total)
+	}
+}
+
+// TestEvictionsSurviveConfigure checks that replacing the cache keeps the
+// evictions of the one it replaces
+func TestEvictionsSurviveConfigure(t *testing.T) {
+	c, err := NewTM2Client(testConfig(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, code := range []string{"SP00", "SP01", "SP02"} {
+		if _, err := c.SearchByCode(context.Background(), code, "code", repository.SearchOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := c.GetCacheStats().Evictions; got != 2 {
+		t.Fatalf("got %d evictions before Configure, want 2", got)
+	}
+
+	if err := c.Configure(testConfig(1000)); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.GetCacheStats().Evictions; got != 2 {
+		t.Errorf("got %d evictions after Configure, want 2", got)
+	}
+}
@@ -1,10 +1,14 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 
+	"github.com/fsnotify/fsnotify"
 	"github.com/spf13/viper"
 )
 
@@ -12,12 +16,22 @@ type Config struct {
 	Cache   CacheConfig   `mapstructure:"cache"`
 	Display DisplayConfig `mapstructure:"display"`
 	CSV     CSVConfig     `mapstructure:"csv"`
+	Search  SearchConfig  `mapstructure:"search"`
+	// Keys rebinds TUI actions, by group and then action as named by the
+	// keymap package
+	Keys map[string]map[string][]string `mapstructure:"keys"`
 }
 
 type CSVConfig struct {
 	FilePath string `mapstructure:"file_path"`
 }
 
+type SearchConfig struct {
+	SynonymsFile string   `mapstructure:"synonyms_file"`
+	Language     string   `mapstructure:"language"`
+	StopWords    []string `mapstructure:"stop_words"`
+}
+
 type CacheConfig struct {
 	Enabled  bool   `mapstructure:"enabled"`
 	TTL      string `mapstructure:"ttl"`
@@ -31,25 +45,93 @@ type DisplayConfig struct {
 	AutoRefresh bool   `mapstructure:"auto_refresh"`
 }
 
+// Sources a configuration value can come from
+const (
+	SourceDefault    = "default"
+	SourceFile       = "file"
+	SourceEnv        = "env"
+	SourceFlag       = "flag"
+	SourceDiscovered = "discovered"
+	SourceUnset      = "unset"
+)
+
+// EnvPrefix prefixes the environment variable overriding each key, so
+// MEDCLI_CACHE_TTL overrides cache.ttl
+const EnvPrefix = "MEDCLI"
+
+// DataEnv names the CSV file, taking precedence over the config file
+const DataEnv = "MEDCLI_DATA"
+
+// Options adjusts how Load resolves the configuration
+type Options struct {
+	// DataPath is the CSV file given with --data; it beats every other source
+	DataPath string
+}
+
+// Setting is the effective value of one configuration key and where it
+// came from
+type Setting struct {
+	Field
+	Value  interface{}
+	Source string
+}
+
+// Loaded is a configuration together with how it was assembled
+type Loaded struct {
+	Config *Config
+	// File is the config file in use, or empty when running on defaults
+	File     string
+	Settings []Setting
+	// DataRule describes which rule picked Config.CSV.FilePath
+	DataRule string
+	options  Options
+}
+
+// SearchPaths lists the directories searched for config.yaml, in order
+var SearchPaths = []string{
+	".",                      // Current directory
+	"$HOME/.medCli",          // User config
+	"/etc/medCli/",           // System config
+	"/usr/local/etc/medCli/", // Local config
+}
+
 func LoadConfig() (*Config, error) {
+	loaded, err := Load(Options{})
+	if err != nil {
+		return nil, err
+	}
+	return loaded.Config, nil
+}
+
+// Load reads the configuration and records the source of every value.
+// Each key is taken from its MEDCLI_ environment variable, then the config
+// file, then its default. The CSV file is resolved by resolveDataPath.
+func Load(opts Options) (*Loaded, error) {
 	v := viper.New()
 	v.SetConfigName("config")
 	v.SetConfigType("yaml")
 
+	v.SetEnvPrefix(EnvPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+	// Keys without a default are only unmarshalled from the environment once bound
+	for _, field := range Fields {
+		if err := v.BindEnv(field.Key); err != nil {
+			return nil, err
+		}
+	}
+
 	// Search in multiple locations
-	v.AddConfigPath(".")                      // Current directory
-	v.AddConfigPath("$HOME/.medCli")          // User config
-	v.AddConfigPath("/etc/medCli/")           // System config
-	v.AddConfigPath("/usr/local/etc/medCli/") // Local config
+	for _, path := range SearchPaths {
+		v.AddConfigPath(path)
+	}
 
 	// Set defaults
-	v.SetDefault("display.theme", "dark")
-	v.SetDefault("display.animations", true)
-	v.SetDefault("display.page_size", 10)
-	v.SetDefault("display.auto_refresh", true)
-	v.SetDefault("cache.enabled", true)
-	v.SetDefault("cache.ttl", "1h")
-	v.SetDefault("cache.max_items", 1000)
+	for _, field := range Fields {
+		if field.Default != nil {
+			v.SetDefault(field.Key, field.Default)
+		}
+	}
 
 	if err := v.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
@@ -64,16 +146,196 @@ func LoadConfig() (*Config, error) {
 		return nil, err
 	}
 
-	// Always find the CSV file dynamically
-	foundCSVPath := findCSVFile()
-	log.Printf("Using CSV file at: %s", foundCSVPath)
-	cfg.CSV.FilePath = foundCSVPath
+	loaded := &Loaded{Config: &cfg, File: v.ConfigFileUsed(), options: opts}
+	for _, field := range Fields {
+		setting := Setting{Field: field, Value: v.Get(field.Key), Source: SourceUnset}
+		switch {
+		case isSet(field.EnvVar()):
+			setting.Source = SourceEnv
+		case v.InConfig(field.Key):
+			setting.Source = SourceFile
+		case field.Default != nil:
+			setting.Source = SourceDefault
+		}
+		loaded.Settings = append(loaded.Settings, setting)
+	}
+
+	loaded.resolveDataPath(opts)
+	if cfg.CSV.FilePath != "" {
+		log.Printf("Using CSV file at: %s (%s)", cfg.CSV.FilePath, loaded.DataRule)
+	}
+
+	return loaded, nil
+}
+
+// resolveDataPath picks the CSV file from, in order, the --data flag, the
+// MEDCLI_DATA variable, the csv.file_path key (itself overridable with
+// MEDCLI_CSV_FILE_PATH) and finally the standard locations
+func (l *Loaded) resolveDataPath(opts Options) {
+	setting := &l.Settings[l.settingIndex("csv.file_path")]
+
+	switch {
+	case opts.DataPath != "":
+		l.Config.CSV.FilePath = opts.DataPath
+		l.DataRule = "--data flag"
+		setting.Source = SourceFlag
+	case os.Getenv(DataEnv) != "":
+		l.Config.CSV.FilePath = os.Getenv(DataEnv)
+		l.DataRule = DataEnv + " environment variable"
+		setting.Source = SourceEnv
+	case setting.Source == SourceEnv:
+		l.DataRule = setting.EnvVar() + " environment variable"
+	case l.Config.CSV.FilePath != "":
+		l.DataRule = "csv.file_path in " + l.File
+	default:
+		path, found := findCSVFile()
+		if !found {
+			l.DataRule = "no data file found"
+			break
+		}
+		l.Config.CSV.FilePath = path
+		l.DataRule = "discovered in a standard location"
+		setting.Source = SourceDiscovered
+	}
+
+	l.Config.CSV.FilePath = os.ExpandEnv(l.Config.CSV.FilePath)
+	setting.Value = l.Config.CSV.FilePath
+}
+
+func (l *Loaded) settingIndex(key string) int {
+	for i, setting := range l.Settings {
+		if setting.Key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+func isSet(env string) bool {
+	value, ok := os.LookupEnv(env)
+	return ok && value != ""
+}
+
+// Watch calls onChange whenever the config file in use is edited. When no
+// file is in use it watches for the user config file being created. The
+// configuration is loaded afresh with the same options, so environment
+// variables and flags still take precedence, and validated; err is set
+// when the edited file cannot be read or holds invalid values. Watch
+// reports false when there is nothing it can watch.
+func (l *Loaded) Watch(onChange func(reloaded *Loaded, err error)) bool {
+	path := l.File
+	if path == "" {
+		path = UserConfigPath()
+		if info, err := os.Stat(filepath.Dir(path)); err != nil || !info.IsDir() {
+			return false
+		}
+	}
+
+	w := viper.New()
+	w.SetConfigFile(path)
+	w.OnConfigChange(func(fsnotify.Event) {
+		reloaded, err := Load(l.options)
+		if err == nil {
+			err = Validate(reloaded.Config)
+		}
+		onChange(reloaded, err)
+	})
+	w.WatchConfig()
+	return true
+}
+
+// Setting returns the effective setting for key
+func (l *Loaded) Setting(key string) (Setting, bool) {
+	for _, setting := range l.Settings {
+		if setting.Key == key {
+			return setting, true
+		}
+	}
+	return Setting{}, false
+}
+
+// ErrConfigExists is returned by Init when the user config file exists
+var ErrConfigExists = errors.New("config file already exists")
+
+// UserConfigPath is the config file written by Set and Init
+func UserConfigPath() string {
+	return filepath.Join(os.ExpandEnv("$HOME"), ".medCli", "config.yaml")
+}
+
+// Set parses value for key, validates it and writes it to the user config
+// file, leaving the other keys in that file untouched
+func Set(key, value string) error {
+	field, ok := LookupField(key)
+	if !ok {
+		return fmt.Errorf("unknown config key %q", key)
+	}
+	parsed, err := field.Parse(value)
+	if err != nil {
+		return err
+	}
+
+	// Validate the new value against the defaults and the rest of the file
+	path := UserConfigPath()
+	v := viper.New()
+	v.SetConfigFile(path)
+	if fileExists(path) {
+		if err := v.ReadInConfig(); err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+	}
+	v.Set(field.Key, parsed)
+
+	check := viper.New()
+	for _, f := range Fields {
+		if f.Default != nil {
+			check.SetDefault(f.Key, f.Default)
+		}
+	}
+	if err := check.MergeConfigMap(v.AllSettings()); err != nil {
+		return err
+	}
+	var cfg Config
+	if err := check.Unmarshal(&cfg); err != nil {
+		return err
+	}
+	if errs, ok := Validate(&cfg).(ValidationErrors); ok {
+		if fieldErr, found := errs.For(field.Key); found {
+			return fieldErr
+		}
+	}
 
-	return &cfg, nil
+	return write(v, path)
+}
+
+// Init writes a config file holding every default to the user config path.
+// An existing file is only replaced when force is set.
+func Init(force bool) (string, error) {
+	path := UserConfigPath()
+	if fileExists(path) && !force {
+		return path, fmt.Errorf("%s: %w", path, ErrConfigExists)
+	}
+
+	v := viper.New()
+	for _, field := range Fields {
+		if field.Default != nil {
+			v.Set(field.Key, field.Default)
+		}
+	}
+	return path, write(v, path)
+}
+
+func write(v *viper.Viper, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := v.WriteConfigAs(path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
 }
 
 // findCSVFile tries to locate the CSV file in various locations
-func findCSVFile() string {
+func findCSVFile() (string, bool) {
 	// Get executable path to find data relative to binary
 	exePath, err := os.Executable()
 	if err != nil {
@@ -124,12 +386,12 @@ func findCSVFile() string {
 		expandedPath := os.ExpandEnv(path)
 		if fileExists(expandedPath) {
 			log.Printf("Found CSV file at: %s", expandedPath)
-			return expandedPath
+			return expandedPath, true
 		}
 	}
 
 	log.Printf("CSV file not found in any standard location")
-	return "medicine_data.csv" // Fallback
+	return "", false
 }
 
 // isSystemBinary checks if the binary is installed in a system directory
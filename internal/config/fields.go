@@ -0,0 +1,188 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Nexusrex18/medCli/internal/analysis"
+	"github.com/Nexusrex18/medCli/internal/keymap"
+	"github.com/Nexusrex18/medCli/internal/theme"
+)
+
+// Kind is the type of value a configuration key holds
+type Kind string
+
+const (
+	KindString   Kind = "string"
+	KindBool     Kind = "bool"
+	KindInt      Kind = "int"
+	KindDuration Kind = "duration"
+	KindList     Kind = "list" // comma-separated on the command line
+)
+
+// Field describes one configuration key
+type Field struct {
+	Key     string
+	Kind    Kind
+	Default interface{} // nil when the key has no default
+	Help    string
+}
+
+// Fields lists every configuration key in display order
+var Fields = []Field{
+	{Key: "cache.enabled", Kind: KindBool, Default: true, Help: "cache search results"},
+	{Key: "cache.ttl", Kind: KindDuration, Default: "1h", Help: "how long a cached result stays valid"},
+	{Key: "cache.max_items", Kind: KindInt, Default: 1000, Help: "cached results kept before the least recently used is evicted; 0 for no limit"},
+	{Key: "display.theme", Kind: KindString, Default: "dark", Help: "colour theme: dark, light, high-contrast or a YAML file in ~/.medCli/themes"},
+	{Key: "display.animations", Kind: KindBool, Default: true, Help: "animate the interface"},
+	{Key: "display.page_size", Kind: KindInt, Default: 10, Help: "results shown per page"},
+	{Key: "display.auto_refresh", Kind: KindBool, Default: true, Help: "refresh the health dashboard automatically"},
+	{Key: "csv.file_path", Kind: KindString, Help: "dataset CSV file; discovered when unset"},
+	{Key: "search.synonyms_file", Kind: KindString, Default: "$HOME/.medCli/synonyms.txt", Help: "extra synonym groups, one comma-separated group per line"},
+	{Key: "search.language", Kind: KindString, Default: analysis.DefaultLanguage, Help: "analysis language: " + strings.Join(analysis.Languages(), ", ")},
+	{Key: "search.stop_words", Kind: KindList, Help: "extra words ignored in searches"},
+}
+
+const (
+	minPageSize = 1
+	maxPageSize = 100
+)
+
+// LookupField returns the field for key
+func LookupField(key string) (Field, bool) {
+	key = strings.ToLower(strings.TrimSpace(key))
+	for _, field := range Fields {
+		if field.Key == key {
+			return field, true
+		}
+	}
+	return Field{}, false
+}
+
+// EnvVar is the environment variable that overrides the field
+func (f Field) EnvVar() string {
+	return EnvPrefix + "_" + strings.ToUpper(strings.ReplaceAll(f.Key, ".", "_"))
+}
+
+// Parse converts a value given on the command line to the field's type
+func (f Field) Parse(value string) (interface{}, error) {
+	value = strings.TrimSpace(value)
+	switch f.Kind {
+	case KindBool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be true or false", f.Key)
+		}
+		return b, nil
+	case KindInt:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a whole number", f.Key)
+		}
+		return n, nil
+	case KindList:
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return items, nil
+	}
+	return value, nil
+}
+
+// Format renders a value of the field for display
+func (f Field) Format(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case []string:
+		return strings.Join(v, ", ")
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = fmt.Sprint(item)
+		}
+		return strings.Join(items, ", ")
+	}
+	return fmt.Sprint(value)
+}
+
+// FieldError is a configuration value that failed validation
+type FieldError struct {
+	Key string
+	Msg string
+}
+
+func (e FieldError) Error() string {
+	return e.Key + ": " + e.Msg
+}
+
+// ValidationErrors lists every invalid value in a configuration
+type ValidationErrors []FieldError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// For returns the error for key, if any
+func (e ValidationErrors) For(key string) (FieldError, bool) {
+	for _, err := range e {
+		if err.Key == key {
+			return err, true
+		}
+	}
+	return FieldError{}, false
+}
+
+// Validate checks every value in cfg and returns ValidationErrors listing
+// all the problems, or nil
+func Validate(cfg *Config) error {
+	var errs ValidationErrors
+	add := func(key, format string, args ...interface{}) {
+		errs = append(errs, FieldError{Key: key, Msg: fmt.Sprintf(format, args...)})
+	}
+
+	if ttl, err := time.ParseDuration(cfg.Cache.TTL); err != nil {
+		add("cache.ttl", "%q is not a duration such as 30m or 1h", cfg.Cache.TTL)
+	} else if ttl <= 0 {
+		add("cache.ttl", "must be greater than zero")
+	}
+	if cfg.Cache.MaxItems < 0 {
+		add("cache.max_items", "must be zero or more")
+	}
+	if strings.TrimSpace(cfg.Display.Theme) == "" {
+		add("display.theme", "must not be empty")
+	} else if _, err := theme.Load(cfg.Display.Theme, theme.Dir()); err != nil {
+		add("display.theme", "%v", err)
+	}
+	if cfg.Display.PageSize < minPageSize || cfg.Display.PageSize > maxPageSize {
+		add("display.page_size", "must be between %d and %d", minPageSize, maxPageSize)
+	}
+	if cfg.CSV.FilePath == "" {
+		add("csv.file_path", "no data file found in the standard locations; set csv.file_path or %s, or pass --data", DataEnv)
+	} else if !fileExists(os.ExpandEnv(cfg.CSV.FilePath)) {
+		add("csv.file_path", "%s does not exist", cfg.CSV.FilePath)
+	}
+	if _, err := analysis.New(cfg.Search.Language); err != nil {
+		add("search.language", "must be one of %s", strings.Join(analysis.Languages(), ", "))
+	}
+	if keyErrs, ok := keymap.Check(cfg.Keys).(keymap.Errors); ok {
+		for _, err := range keyErrs {
+			add("keys."+err.Action, "%s", err.Msg)
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
@@ -0,0 +1,196 @@
+// Package history keeps the searches made in the TUI across sessions. The
+// history file holds one search per line, oldest first: the time, the
+// search type and the query, separated by tabs.
+package history
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// MaxEntries is how many searches are kept; older ones are dropped
+const MaxEntries = 1000
+
+// Entry is one search
+type Entry struct {
+	Time  time.Time `json:"time"`
+	Type  string    `json:"type"` // code, symptoms or query
+	Query string    `json:"query"`
+}
+
+// Path is the history file of the current user
+func Path() string {
+	return filepath.Join(os.ExpandEnv("$HOME"), ".medCli", "history")
+}
+
+// History is the search history stored in a file
+type History struct {
+	path    string
+	entries []Entry // oldest first
+}
+
+// Open reads the history stored at path. A missing file is an empty
+// history. Lines that cannot be parsed are skipped, and when the file
+// cannot be read the returned History is empty but still usable.
+func Open(path string) (*History, error) {
+	h := &History{path: path}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return h, fmt.Errorf("failed to read history: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if entry, ok := parse(scanner.Text()); ok {
+			h.entries = append(h.entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return h, fmt.Errorf("failed to read history: %w", err)
+	}
+	if len(h.entries) > MaxEntries {
+		h.entries = h.entries[len(h.entries)-MaxEntries:]
+	}
+	return h, nil
+}
+
+func parse(line string) (Entry, bool) {
+	fields := strings.SplitN(line, "\t", 3)
+	if len(fields) != 3 || fields[2] == "" {
+		return Entry{}, false
+	}
+	t, err := time.Parse(time.RFC3339, fields[0])
+	if err != nil {
+		return Entry{}, false
+	}
+	return Entry{Time: t, Type: fields[1], Query: fields[2]}, true
+}
+
+func (e Entry) line() string {
+	return e.Time.Format(time.RFC3339) + "\t" + e.Type + "\t" + e.Query + "\n"
+}
+
+// Path is the file the history is stored in
+func (h *History) Path() string {
+	return h.path
+}
+
+// Entries lists the searches, oldest first
+func (h *History) Entries() []Entry {
+	return h.entries
+}
+
+// Add records a search and appends it to the file. Blank queries and
+// repeats of the latest search are not recorded.
+func (h *History) Add(entry Entry) error {
+	// Tabs and line breaks would split the line
+	entry.Query = strings.Join(strings.Fields(entry.Query), " ")
+	if entry.Query == "" {
+		return nil
+	}
+	if n := len(h.entries); n > 0 && h.entries[n-1].Type == entry.Type && h.entries[n-1].Query == entry.Query {
+		return nil
+	}
+
+	h.entries = append(h.entries, entry)
+	if len(h.entries) > MaxEntries {
+		h.entries = h.entries[len(h.entries)-MaxEntries:]
+		return h.rewrite()
+	}
+
+	if err := os.MkdirAll(filepath.Dir(h.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+	// Queries can describe patients, so the file is private
+	f, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	if _, err := f.WriteString(entry.line()); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	return f.Close()
+}
+
+// rewrite replaces the file with the entries held in memory
+func (h *History) rewrite() error {
+	var b strings.Builder
+	for _, entry := range h.entries {
+		b.WriteString(entry.line())
+	}
+	if err := os.WriteFile(h.path, []byte(b.String()), 0o600); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	return nil
+}
+
+// Clear forgets every search and removes the file
+func (h *History) Clear() error {
+	h.entries = nil
+	if err := os.Remove(h.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to clear history: %w", err)
+	}
+	return nil
+}
+
+// Recall is a cursor over the queries of the searches of some types,
+// newest first, as recalled with the arrow keys or searched with Ctrl-R
+type Recall struct {
+	queries []string // newest first, without repeats
+}
+
+// NewRecall lists the queries of the searches of the given types
+func (h *History) NewRecall(types ...string) Recall {
+	var r Recall
+	seen := make(map[string]bool)
+	for i := len(h.entries) - 1; i >= 0; i-- {
+		entry := h.entries[i]
+		if !hasType(types, entry.Type) || seen[entry.Query] {
+			continue
+		}
+		seen[entry.Query] = true
+		r.queries = append(r.queries, entry.Query)
+	}
+	return r
+}
+
+func hasType(types []string, t string) bool {
+	for _, want := range types {
+		if want == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Len is the number of queries that can be recalled
+func (r Recall) Len() int {
+	return len(r.queries)
+}
+
+// At is the query n searches back, counting the newest as 0
+func (r Recall) At(n int) string {
+	return r.queries[n]
+}
+
+// Find returns the position of the newest query at or after from that
+// contains pattern, ignoring case, or -1 when there is none
+func (r Recall) Find(pattern string, from int) int {
+	pattern = strings.ToLower(pattern)
+	for i := max(from, 0); i < len(r.queries); i++ {
+		if strings.Contains(strings.ToLower(r.queries[i]), pattern) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,311 @@
+// Package keymap names the actions of the TUI and the keys bound to them.
+// Any action can be rebound in the keys section of the config, grouped by
+// screen as in the action names:
+//
+//	keys:
+//	  list:
+//	    down: [down, ctrl+n]
+//	    up: [up, ctrl+p]
+//	  global:
+//	    help: f1
+//
+// An override replaces every default key of its action, and an empty
+// list unbinds it. Keys are written as bubbletea names them, such as
+// "ctrl+n", "shift+tab", "pgdown", "alt+x" or a single character, with
+// "space" for the space bar. Two actions that are live on the same screen
+// cannot share a key.
+package keymap
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Action is something a key does in the TUI
+type Action struct {
+	Name string   // "<group>.<action>", as in the keys config section
+	Keys []string // default keys
+	Help string
+}
+
+// Group is the part of the name before the dot
+func (a Action) Group() string {
+	group, _, _ := strings.Cut(a.Name, ".")
+	return group
+}
+
+// Actions lists every action in the order the help screen shows them
+var Actions = []Action{
+	{"global.help", []string{"?"}, "help"},
+	{"global.quit", []string{"ctrl+c"}, "quit"},
+
+	{"intro.continue", []string{"enter", " "}, "continue"},
+	{"intro.quit", []string{"q"}, "quit"},
+
+	{"menu.up", []string{"up", "k"}, "up"},
+	{"menu.down", []string{"down", "j"}, "down"},
+	{"menu.select", []string{"enter"}, "select"},
+	{"menu.quit", []string{"q"}, "quit"},
+
+	{"search.submit", []string{"enter"}, "search"},
+	{"search.grouped", []string{"tab"}, "grouped view"},
+	{"search.cancel", []string{"esc"}, "cancel search"},
+	{"search.back", []string{"q"}, "back to menu"},
+
+	{"history.prev", []string{"up"}, "previous search"},
+	{"history.next", []string{"down"}, "next search"},
+	{"history.search", []string{"ctrl+r"}, "search history"},
+
+	{"list.up", []string{"up", "k"}, "previous"},
+	{"list.down", []string{"down", "j"}, "next"},
+	{"list.page_up", []string{"pgup"}, "previous page"},
+	{"list.page_down", []string{"pgdown"}, "next page"},
+	{"list.home", []string{"home"}, "first"},
+	{"list.end", []string{"end"}, "last"},
+	{"list.jump", []string{"ctrl+g"}, "go to result"},
+	{"list.star", []string{"*", "ctrl+b"}, "bookmark"},
+
+	{"bookmarks.remove", []string{"delete", "ctrl+d"}, "remove bookmark"},
+
+	{"jump.go", []string{"enter"}, "go"},
+	{"jump.cancel", []string{"esc"}, "cancel"},
+
+	{"detail.next", []string{"n", "right", "l"}, "next record"},
+	{"detail.prev", []string{"p", "left", "h"}, "previous record"},
+	{"detail.similar", []string{"s"}, "similar records"},
+	{"detail.star", []string{"*"}, "bookmark"},
+	{"detail.close", []string{"esc", "q", "enter"}, "close"},
+
+	{"scroll.up", []string{"up", "k"}, "scroll up"},
+	{"scroll.down", []string{"down", "j"}, "scroll down"},
+	{"scroll.page_up", []string{"pgup", "b"}, "page up"},
+	{"scroll.page_down", []string{"pgdown", " ", "f"}, "page down"},
+	{"scroll.half_page_up", []string{"u", "ctrl+u"}, "half page up"},
+	{"scroll.half_page_down", []string{"d", "ctrl+d"}, "half page down"},
+
+	{"health.refresh", []string{"r"}, "refresh"},
+	{"health.back", []string{"q", "esc"}, "back to menu"},
+
+	{"settings.up", []string{"up", "k"}, "up"},
+	{"settings.down", []string{"down", "j"}, "down"},
+	{"settings.edit", []string{"enter", " "}, "edit/toggle"},
+	{"settings.back", []string{"q", "esc"}, "back to menu"},
+	{"settings.save", []string{"enter"}, "save"},
+	{"settings.cancel", []string{"esc"}, "cancel"},
+
+	{"help.next", []string{"tab", "right", "l"}, "next topic"},
+	{"help.prev", []string{"shift+tab", "left", "h"}, "previous topic"},
+	{"help.back", []string{"q", "esc"}, "back to menu"},
+}
+
+// scopes lists the actions that are live at the same time, by group or
+// by name. Keys must be unique within a scope.
+var scopes = [][]string{
+	{"global", "intro"},
+	{"global", "menu"},
+	{"global", "search", "list"},
+	{"global", "search", "history"},
+	{"global", "search", "list", "bookmarks"},
+	{"global.quit", "jump"},
+	{"global", "detail", "scroll"},
+	{"global", "health", "scroll"},
+	{"global", "settings.up", "settings.down", "settings.edit", "settings.back"},
+	{"global.quit", "settings.save", "settings.cancel"},
+	{"global", "help", "scroll"},
+}
+
+// Lookup returns the action called name
+func Lookup(name string) (Action, bool) {
+	for _, action := range Actions {
+		if action.Name == name {
+			return action, true
+		}
+	}
+	return Action{}, false
+}
+
+// Defaults returns the default keys of every action by name
+func Defaults() map[string][]string {
+	defaults := make(map[string][]string, len(Actions))
+	for _, action := range Actions {
+		defaults[action.Name] = action.Keys
+	}
+	return defaults
+}
+
+// Error is an override that cannot be applied
+type Error struct {
+	Action string
+	Msg    string
+}
+
+func (e Error) Error() string {
+	return "keys." + e.Action + ": " + e.Msg
+}
+
+// Errors lists every override that cannot be applied
+type Errors []Error
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Resolve applies overrides, keyed by group and then action as in the
+// config, to the default keys. It returns the keys of every action by
+// name, or Errors naming unknown actions and keys and the keys claimed by
+// two actions on one screen.
+func Resolve(overrides map[string]map[string][]string) (map[string][]string, error) {
+	var errs Errors
+	resolved := Defaults()
+
+	for _, name := range overrideNames(overrides) {
+		group, action, _ := strings.Cut(name, ".")
+		if _, ok := Lookup(name); !ok {
+			errs = append(errs, Error{name, "unknown action"})
+			continue
+		}
+
+		var keys []string
+		for _, k := range overrides[group][action] {
+			normalized, ok := normalize(k)
+			if !ok {
+				errs = append(errs, Error{name, fmt.Sprintf("%q is not a key name", k)})
+				continue
+			}
+			keys = append(keys, normalized)
+		}
+		resolved[name] = keys
+	}
+
+	errs = append(errs, conflicts(resolved, overrides)...)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return resolved, nil
+}
+
+// Check reports the overrides Resolve would reject
+func Check(overrides map[string]map[string][]string) error {
+	_, err := Resolve(overrides)
+	return err
+}
+
+// overrideNames lists the action names in overrides, sorted so errors
+// come out in a stable order
+func overrideNames(overrides map[string]map[string][]string) []string {
+	var names []string
+	for group, actions := range overrides {
+		for action := range actions {
+			names = append(names, group+"."+action)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// conflicts finds keys bound to two actions in one scope. Each clash is
+// reported once, against an overridden action so the error points at the
+// config entry to change.
+func conflicts(resolved map[string][]string, overrides map[string]map[string][]string) Errors {
+	overridden := func(name string) bool {
+		group, action, _ := strings.Cut(name, ".")
+		_, ok := overrides[group][action]
+		return ok
+	}
+
+	var errs Errors
+	reported := make(map[string]bool)
+	for _, scope := range scopes {
+		owner := make(map[string]string) // key -> first action in the scope using it
+		for _, action := range Actions {
+			if !inScope(action, scope) {
+				continue
+			}
+			for _, k := range resolved[action.Name] {
+				other, taken := owner[k]
+				if !taken {
+					owner[k] = action.Name
+					continue
+				}
+				if other == action.Name {
+					continue
+				}
+				name, rival := action.Name, other
+				if !overridden(name) && overridden(rival) {
+					name, rival = rival, name
+				}
+				clash := name + " " + rival + " " + k
+				if !reported[clash] {
+					reported[clash] = true
+					errs = append(errs, Error{name, fmt.Sprintf("%s is already bound to %s", Name(k), rival)})
+				}
+			}
+		}
+	}
+	return errs
+}
+
+func inScope(action Action, scope []string) bool {
+	for _, entry := range scope {
+		if entry == action.Name || entry == action.Group() {
+			return true
+		}
+	}
+	return false
+}
+
+// namedKeys are the keys bubbletea reports by name rather than as the
+// character typed
+var namedKeys = map[string]bool{
+	"enter": true, "tab": true, "shift+tab": true, "esc": true, "backspace": true, "delete": true, "insert": true,
+	"up": true, "down": true, "left": true, "right": true, "home": true, "end": true, "pgup": true, "pgdown": true,
+	"ctrl+up": true, "ctrl+down": true, "ctrl+left": true, "ctrl+right": true,
+	"shift+up": true, "shift+down": true, "shift+left": true, "shift+right": true,
+	"ctrl+shift+up": true, "ctrl+shift+down": true, "ctrl+shift+left": true, "ctrl+shift+right": true,
+	"ctrl+home": true, "ctrl+end": true, "shift+home": true, "shift+end": true, "ctrl+shift+home": true, "ctrl+shift+end": true,
+	"ctrl+pgup": true, "ctrl+pgdown": true,
+	"ctrl+@": true, `ctrl+\`: true, "ctrl+]": true, "ctrl+^": true, "ctrl+_": true,
+}
+
+func init() {
+	for c := 'a'; c <= 'z'; c++ {
+		// ctrl+i and ctrl+m arrive as tab and enter
+		if c != 'i' && c != 'm' {
+			namedKeys["ctrl+"+string(c)] = true
+		}
+	}
+	for n := 1; n <= 20; n++ {
+		namedKeys[fmt.Sprintf("f%d", n)] = true
+	}
+}
+
+// normalize returns k as bubbletea reports it, or false when bubbletea
+// never reports such a key
+func normalize(k string) (string, bool) {
+	if k == "space" {
+		return " ", true
+	}
+	if k == " " || namedKeys[k] {
+		return k, true
+	}
+	if rest, ok := strings.CutPrefix(k, "alt+"); ok && rest != "" {
+		normalized, ok := normalize(rest)
+		return "alt+" + normalized, ok
+	}
+	// A character key
+	return k, utf8.RuneCountInString(k) == 1
+}
+
+// Name is k as written in the config
+func Name(k string) string {
+	if k == " " {
+		return "space"
+	}
+	return k
+}
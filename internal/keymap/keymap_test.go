@@ -0,0 +1,201 @@
+package keymap
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type overrides = map[string]map[string][]string
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		name      string
+		overrides overrides
+		action    string   // an action to check the keys of
+		keys      []string // its keys, when there is no error
+		errs      []string
+	}{
+		{
+			name:   "defaults",
+			action: "list.down",
+			keys:   []string{"down", "j"},
+		},
+		{
+			name:      "an override replaces every default key",
+			overrides: overrides{"list": {"down": {"ctrl+n"}}},
+			action:    "list.down",
+			keys:      []string{"ctrl+n"},
+		},
+		{
+			name:      "an empty list unbinds the action",
+			overrides: overrides{"global": {"help": {}}},
+			action:    "global.help",
+			keys:      nil,
+		},
+		{
+			name:      "space and alt keys are normalised",
+			overrides: overrides{"scroll": {"page_down": {"space", "alt+j", "alt+space"}}},
+			action:    "scroll.page_down",
+			keys:      []string{" ", "alt+j", "alt+ "},
+		},
+		{
+			name:      "a key may be reused on another screen",
+			overrides: overrides{"menu": {"quit": {"r"}}},
+			action:    "menu.quit",
+			keys:      []string{"r"},
+		},
+		{
+			name:      "a key may be reused by actions never live together",
+			overrides: overrides{"settings": {"save": {"ctrl+s"}, "up": {"ctrl+s"}}},
+			action:    "settings.save",
+			keys:      []string{"ctrl+s"},
+		},
+		{
+			name:      "unknown action",
+			overrides: overrides{"list": {"sideways": {"x"}}},
+			errs:      []string{"keys.list.sideways: unknown action"},
+		},
+		{
+			name:      "unknown group",
+			overrides: overrides{"popup": {"close": {"x"}}},
+			errs:      []string{"keys.popup.close: unknown action"},
+		},
+		{
+			name:      "not a key name",
+			overrides: overrides{"global": {"help": {"ctrl+shift+q", "f1"}}},
+			errs:      []string{`keys.global.help: "ctrl+shift+q" is not a key name`},
+		},
+		{
+			name:      "words are not keys",
+			overrides: overrides{"global": {"help": {"help"}}},
+			errs:      []string{`keys.global.help: "help" is not a key name`},
+		},
+		{
+			name:      "ctrl+i arrives as tab",
+			overrides: overrides{"global": {"help": {"ctrl+i"}}},
+			errs:      []string{`keys.global.help: "ctrl+i" is not a key name`},
+		},
+		{
+			name:      "conflict with a default on the same screen",
+			overrides: overrides{"list": {"jump": {"enter"}}},
+			errs:      []string{"keys.list.jump: enter is already bound to search.submit"},
+		},
+		{
+			name:      "conflict with a global key",
+			overrides: overrides{"detail": {"similar": {"?"}}},
+			errs:      []string{"keys.detail.similar: ? is already bound to global.help"},
+		},
+		{
+			name:      "the clash is reported against the override",
+			overrides: overrides{"global": {"help": {"j"}}},
+			errs: []string{
+				"keys.global.help: j is already bound to menu.down",
+				"keys.global.help: j is already bound to list.down",
+				"keys.global.help: j is already bound to scroll.down",
+				"keys.global.help: j is already bound to settings.down",
+			},
+		},
+		{
+			name:      "two overrides that clash",
+			overrides: overrides{"health": {"refresh": {"ctrl+x"}, "back": {"ctrl+x"}}},
+			errs:      []string{"keys.health.back: ctrl+x is already bound to health.refresh"},
+		},
+		{
+			name:      "the bookmarks keys share a screen with the list and search keys",
+			overrides: overrides{"bookmarks": {"remove": {"ctrl+g"}}},
+			errs:      []string{"keys.bookmarks.remove: ctrl+g is already bound to list.jump"},
+		},
+		{
+			name:      "errors of every override are reported together",
+			overrides: overrides{"list": {"sideways": {"x"}}, "menu": {"select": {"ctrl+shift+q"}}},
+			errs: []string{
+				"keys.list.sideways: unknown action",
+				`keys.menu.select: "ctrl+shift+q" is not a key name`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolved, err := Resolve(tt.overrides)
+			if tt.errs != nil {
+				var errs Errors
+				if !errors.As(err, &errs) {
+					t.Fatalf("Resolve() error = %v, want Errors", err)
+				}
+				var got []string
+				for _, e := range errs {
+					got = append(got, e.Error())
+				}
+				if !reflect.DeepEqual(got, tt.errs) {
+					t.Errorf("Resolve() errors =\n%q\nwant\n%q", got, tt.errs)
+				}
+				if Check(tt.overrides) == nil {
+					t.Error("Check() accepted overrides Resolve rejects")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Resolve() failed: %v", err)
+			}
+			if got := resolved[tt.action]; !reflect.DeepEqual(got, tt.keys) {
+				t.Errorf("%s = %q, want %q", tt.action, got, tt.keys)
+			}
+			if len(resolved) != len(Actions) {
+				t.Errorf("Resolve() returned %d actions, want %d", len(resolved), len(Actions))
+			}
+		})
+	}
+}
+
+func TestScopesNameActions(t *testing.T) {
+	for _, scope := range scopes {
+		for _, entry := range scope {
+			found := false
+			for _, action := range Actions {
+				if entry == action.Name || entry == action.Group() {
+					found = true
+					break
+				}
+			}
+			if !found {
+				t.Errorf("scope %v names %q, which is no action or group", scope, entry)
+			}
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+		ok   bool
+	}{
+		{"j", "j", true},
+		{"?", "?", true},
+		{"é", "é", true},
+		{"space", " ", true},
+		{" ", " ", true},
+		{"enter", "enter", true},
+		{"pgdown", "pgdown", true},
+		{"ctrl+a", "ctrl+a", true},
+		{"ctrl+m", "", false},
+		{"f12", "f12", true},
+		{"f21", "", false},
+		{"alt+x", "alt+x", true},
+		{"alt+enter", "alt+enter", true},
+		{"alt+", "", false},
+		{"shift+a", "", false},
+		{"Enter", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := normalize(tt.key)
+		if ok != tt.ok || (ok && got != tt.want) {
+			t.Errorf("normalize(%q) = %q, %v, want %q, %v", tt.key, got, ok, tt.want, tt.ok)
+		}
+	}
+}
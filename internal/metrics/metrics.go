@@ -0,0 +1,261 @@
+// Package metrics records search and cache activity and renders it in the
+// Prometheus text exposition format, so a long-running lookup service can
+// be scraped and graphed.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ContentType is the Prometheus text exposition format served by Handler
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+var (
+	// resultBuckets are the upper bounds for the number of matches per search
+	resultBuckets = []float64{0, 1, 5, 10, 25, 50, 100, 250, 500, 1000}
+	// latencyBuckets are the upper bounds, in seconds, for search latency
+	latencyBuckets = []float64{0.0001, 0.00025, 0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1}
+)
+
+// recentSearches is the number of search latencies kept for RecentLatencies
+const recentSearches = 40
+
+// CacheSample is a point-in-time reading of the result cache
+type CacheSample struct {
+	Hits      int
+	Misses    int
+	Evictions int
+	Items     int
+	MaxItems  int
+}
+
+// Registry collects the metrics of one client. It is safe for concurrent use.
+type Registry struct {
+	searches    map[string]*searchMetrics
+	reloads     map[string]int // by result, "success" or "error"
+	loadedAt    time.Time
+	loadTime    time.Duration
+	recent      []time.Duration // ring of the last recentSearches latencies
+	next        int             // where the next latency goes in recent
+	cacheSample func() CacheSample
+	mu          sync.Mutex
+}
+
+type searchMetrics struct {
+	errors  int
+	results *histogram
+	latency *histogram
+}
+
+// New returns an empty registry. The given search types are reported with
+// zero values until they are first observed, so dashboards see them from
+// the start.
+func New(searchTypes ...string) *Registry {
+	r := &Registry{
+		searches: make(map[string]*searchMetrics),
+		reloads:  map[string]int{"success": 0, "error": 0},
+	}
+	for _, searchType := range searchTypes {
+		r.search(searchType)
+	}
+	return r
+}
+
+// ObserveSearch records one search of the given type, the number of
+// records it matched and how long it took
+func (r *Registry) ObserveSearch(searchType string, results int, elapsed time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s := r.search(searchType)
+	if err != nil {
+		s.errors++
+	}
+	s.results.observe(float64(results))
+	s.latency.observe(elapsed.Seconds())
+
+	if len(r.recent) < recentSearches {
+		r.recent = append(r.recent, elapsed)
+	} else {
+		r.recent[r.next] = elapsed
+	}
+	r.next = (r.next + 1) % recentSearches
+}
+
+// ObserveLoad records that the dataset was loaded for the first time and
+// how long that took
+func (r *Registry) ObserveLoad(elapsed time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.loadedAt = time.Now()
+	r.loadTime = elapsed
+}
+
+// ObserveReload records an attempt to reload the dataset and how long it
+// took
+func (r *Registry) ObserveReload(elapsed time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err != nil {
+		r.reloads["error"]++
+		return
+	}
+	r.reloads["success"]++
+	r.loadedAt = time.Now()
+	r.loadTime = elapsed
+}
+
+// LastLoad returns when the dataset was last loaded and how long loading
+// took. loadedAt is zero before the first load is observed.
+func (r *Registry) LastLoad() (loadedAt time.Time, took time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.loadedAt, r.loadTime
+}
+
+// RecentLatencies returns the latencies of the most recent searches of any
+// type, oldest first
+func (r *Registry) RecentLatencies() []time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.recent) < recentSearches {
+		return append([]time.Duration(nil), r.recent...)
+	}
+	return append(append([]time.Duration(nil), r.recent[r.next:]...), r.recent[:r.next]...)
+}
+
+// SetCacheSource registers the function read for cache metrics whenever
+// the registry is written out
+func (r *Registry) SetCacheSource(sample func() CacheSample) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cacheSample = sample
+}
+
+// search returns the metrics for searchType, creating them on first use.
+// Callers must hold the lock.
+func (r *Registry) search(searchType string) *searchMetrics {
+	s, ok := r.searches[searchType]
+	if !ok {
+		s = &searchMetrics{
+			results: newHistogram(resultBuckets),
+			latency: newHistogram(latencyBuckets),
+		}
+		r.searches[searchType] = s
+	}
+	return s
+}
+
+// WriteText writes every metric to w in the Prometheus text format
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	types := make([]string, 0, len(r.searches))
+	for searchType := range r.searches {
+		types = append(types, searchType)
+	}
+	sort.Strings(types)
+
+	var b strings.Builder
+
+	header(&b, "medcli_searches_total", "counter", "Searches run, by search type.")
+	for _, t := range types {
+		fmt.Fprintf(&b, "medcli_searches_total{type=%q} %d\n", t, r.searches[t].latency.count)
+	}
+	header(&b, "medcli_search_errors_total", "counter", "Searches that returned an error, by search type.")
+	for _, t := range types {
+		fmt.Fprintf(&b, "medcli_search_errors_total{type=%q} %d\n", t, r.searches[t].errors)
+	}
+	header(&b, "medcli_search_results", "histogram", "Number of records matched per search, by search type.")
+	for _, t := range types {
+		r.searches[t].results.write(&b, "medcli_search_results", t)
+	}
+	header(&b, "medcli_search_duration_seconds", "histogram", "Search latency in seconds, including cache lookups, by search type.")
+	for _, t := range types {
+		r.searches[t].latency.write(&b, "medcli_search_duration_seconds", t)
+	}
+
+	if r.cacheSample != nil {
+		sample := r.cacheSample()
+		header(&b, "medcli_cache_hits_total", "counter", "Searches answered from the result cache.")
+		fmt.Fprintf(&b, "medcli_cache_hits_total %d\n", sample.Hits)
+		header(&b, "medcli_cache_misses_total", "counter", "Searches that missed the result cache.")
+		fmt.Fprintf(&b, "medcli_cache_misses_total %d\n", sample.Misses)
+		header(&b, "medcli_cache_evictions_total", "counter", "Results evicted from a full cache.")
+		fmt.Fprintf(&b, "medcli_cache_evictions_total %d\n", sample.Evictions)
+		header(&b, "medcli_cache_items", "gauge", "Results currently held in the cache.")
+		fmt.Fprintf(&b, "medcli_cache_items %d\n", sample.Items)
+		header(&b, "medcli_cache_max_items", "gauge", "Cache size limit, or 0 when unbounded.")
+		fmt.Fprintf(&b, "medcli_cache_max_items %d\n", sample.MaxItems)
+	}
+
+	header(&b, "medcli_dataset_reloads_total", "counter", "Dataset reloads, by result.")
+	for _, result := range []string{"error", "success"} {
+		fmt.Fprintf(&b, "medcli_dataset_reloads_total{result=%q} %d\n", result, r.reloads[result])
+	}
+	if !r.loadedAt.IsZero() {
+		header(&b, "medcli_dataset_loaded_timestamp_seconds", "gauge", "Unix time the dataset was last loaded.")
+		fmt.Fprintf(&b, "medcli_dataset_loaded_timestamp_seconds %d\n", r.loadedAt.Unix())
+		header(&b, "medcli_dataset_load_duration_seconds", "gauge", "Time taken by the last successful dataset load.")
+		fmt.Fprintf(&b, "medcli_dataset_load_duration_seconds %s\n", strconv.FormatFloat(r.loadTime.Seconds(), 'g', -1, 64))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Handler serves the registry in the Prometheus text format, for mounting
+// at /metrics in any HTTP mode
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		if err := r.WriteText(w); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+func header(b *strings.Builder, name, kind, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// histogram counts observations into cumulative buckets
+type histogram struct {
+	bounds []float64
+	counts []int // counts[i] is the number of observations <= bounds[i]
+	count  int
+	sum    float64
+}
+
+func newHistogram(bounds []float64) *histogram {
+	return &histogram{bounds: bounds, counts: make([]int, len(bounds))}
+}
+
+func (h *histogram) observe(v float64) {
+	for i, bound := range h.bounds {
+		if v <= bound {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += v
+}
+
+func (h *histogram) write(b *strings.Builder, name, searchType string) {
+	for i, bound := range h.bounds {
+		fmt.Fprintf(b, "%s_bucket{type=%q,le=%q} %d\n", name, searchType, strconv.FormatFloat(bound, 'g', -1, 64), h.counts[i])
+	}
+	fmt.Fprintf(b, "%s_bucket{type=%q,le=\"+Inf\"} %d\n", name, searchType, h.count)
+	fmt.Fprintf(b, "%s_sum{type=%q} %s\n", name, searchType, strconv.FormatFloat(h.sum, 'g', -1, 64))
+	fmt.Fprintf(b, "%s_count{type=%q} %d\n", name, searchType, h.count)
+}
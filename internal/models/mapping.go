@@ -0,0 +1,30 @@
+package models
+
+// Cardinality labels a mapping by how many codes sit on each side of it,
+// read from the traditional code towards the TM2 code.
+type Cardinality string
+
+const (
+	OneToOne   Cardinality = "one-to-one"
+	OneToMany  Cardinality = "one-to-many"
+	ManyToOne  Cardinality = "many-to-one"
+	ManyToMany Cardinality = "many-to-many"
+)
+
+// Cardinalities lists every label in display order
+var Cardinalities = []Cardinality{OneToOne, OneToMany, ManyToOne, ManyToMany}
+
+// Mapping is a single traditional -> TM2 pair with its cardinality label
+type Mapping struct {
+	Record      MedicineRecord `json:"record"`
+	Cardinality Cardinality    `json:"cardinality"`
+}
+
+// MappingGroup collects every mapping that shares one code. System is
+// "tm2" when Code is a TM2 code and "traditional" otherwise.
+type MappingGroup struct {
+	Code     string    `json:"code"`
+	System   string    `json:"system"`
+	Title    string    `json:"title"`
+	Mappings []Mapping `json:"mappings"`
+}
@@ -0,0 +1,42 @@
+package models
+
+// TermMatch explains how one query term was satisfied. Synonym is empty
+// when the term itself was found in the record.
+type TermMatch struct {
+	Term    string `json:"term"`
+	Synonym string `json:"synonym,omitempty"`
+}
+
+// SymptomMatch is a record returned by a symptom search together with
+// the terms that matched it and where they were found
+type SymptomMatch struct {
+	Record     MedicineRecord `json:"record"`
+	Terms      []TermMatch    `json:"terms"`
+	Highlights []Highlight    `json:"highlights"`
+}
+
+// HighlightsFor returns the highlights that fall in one field
+func (m SymptomMatch) HighlightsFor(field string) []Highlight {
+	var highlights []Highlight
+	for _, h := range m.Highlights {
+		if h.Field == field {
+			highlights = append(highlights, h)
+		}
+	}
+	return highlights
+}
+
+// Highlight marks a matched span in one field of a record. Start and End
+// are byte offsets into the field value, and Field uses the JSON name of
+// the field, e.g. "tm2_definition".
+type Highlight struct {
+	Field string `json:"field"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+}
+
+// ScoredRecord is a record ranked by a similarity score between 0 and 1
+type ScoredRecord struct {
+	Record MedicineRecord `json:"record"`
+	Score  float64        `json:"score"`
+}
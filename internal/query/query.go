@@ -0,0 +1,382 @@
+// Package query parses and evaluates the boolean, field-scoped query
+// language used to filter MedicineRecords, for example
+//
+//	title:"kapha" AND NOT definition:cough
+//	type:Unani (fever OR humma)
+//	confidence>0.8
+//
+// Terms next to each other are joined with AND. Bare terms match any text
+// field, and all text matching is case and diacritic insensitive.
+package query
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Nexusrex18/medCli/internal/models"
+	"github.com/Nexusrex18/medCli/internal/textnorm"
+)
+
+// SyntaxError reports where in the query parsing failed
+type SyntaxError struct {
+	Query string
+	Pos   int // byte offset into Query
+	Msg   string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("syntax error at column %d: %s", e.Column(), e.Msg)
+}
+
+// Column is the 1-based rune column of the error
+func (e *SyntaxError) Column() int {
+	return utf8.RuneCountInString(e.Query[:e.Pos]) + 1
+}
+
+// Caret returns the query with a caret on the line below pointing at the
+// error position
+func (e *SyntaxError) Caret() string {
+	return e.Query + "\n" + strings.Repeat(" ", e.Column()-1) + "^"
+}
+
+// Node is a parsed query that can be matched against records
+type Node interface {
+	Match(doc *Document) bool
+	String() string
+}
+
+// fieldIndex is a text field of a record a term can be scoped to
+type fieldIndex int
+
+const (
+	fieldTitle fieldIndex = iota
+	fieldDefinition
+	fieldCode
+	fieldTM2Code
+	fieldCodeTitle
+	fieldDescription
+	fieldType
+	fieldLink
+	fieldCount
+)
+
+// textFields maps field names to the record text they search
+var textFields = map[string]fieldIndex{
+	"title":            fieldTitle,
+	"tm2_title":        fieldTitle,
+	"definition":       fieldDefinition,
+	"tm2_definition":   fieldDefinition,
+	"code":             fieldCode,
+	"tm2_code":         fieldTM2Code,
+	"tm2":              fieldTM2Code,
+	"code_title":       fieldCodeTitle,
+	"description":      fieldDescription,
+	"code_description": fieldDescription,
+	"type":             fieldType,
+	"link":             fieldLink,
+}
+
+// anyFields are the fields a bare term searches
+var anyFields = []fieldIndex{fieldTitle, fieldDefinition, fieldCodeTitle, fieldDescription, fieldCode, fieldTM2Code}
+
+// numericFields maps field names to the record number they compare
+var numericFields = map[string]func(models.MedicineRecord) float64{
+	"confidence":       func(r models.MedicineRecord) float64 { return r.ConfidenceScore },
+	"confidence_score": func(r models.MedicineRecord) float64 { return r.ConfidenceScore },
+}
+
+// Document is a record prepared for matching. Its text fields are folded
+// once when the data is indexed rather than on every query.
+type Document struct {
+	Record models.MedicineRecord
+	text   [fieldCount]string
+}
+
+// NewDocument folds the text fields of record
+func NewDocument(record models.MedicineRecord) Document {
+	doc := Document{Record: record}
+	for f, text := range [fieldCount]string{
+		fieldTitle:       record.TM2Title,
+		fieldDefinition:  record.TM2Definition,
+		fieldCode:        record.Code,
+		fieldTM2Code:     record.TM2Code,
+		fieldCodeTitle:   record.CodeTitle,
+		fieldDescription: record.Description,
+		fieldType:        record.Type,
+		fieldLink:        record.TM2Link,
+	} {
+		doc.text[f] = textnorm.Fold(text)
+	}
+	return doc
+}
+
+// Fields lists every field name the language accepts
+func Fields() []string {
+	names := []string{}
+	for name := range textFields {
+		names = append(names, name)
+	}
+	for name := range numericFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LooksLikeQuery reports whether input uses query syntax rather than a
+// plain comma-separated symptom list
+func LooksLikeQuery(input string) bool {
+	tokens, err := lex(input)
+	if err != nil {
+		return true
+	}
+	for _, tok := range tokens {
+		switch tok.kind {
+		case tokAnd, tokOr, tokNot, tokLParen, tokRParen, tokColon, tokCompare, tokString:
+			return true
+		}
+	}
+	return false
+}
+
+// Parse compiles input into a Node. Errors are *SyntaxError.
+func Parse(input string) (Node, error) {
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{input: input, tokens: tokens}
+	if p.peek().kind == tokEOF {
+		return nil, p.errorf(p.peek(), "empty query")
+	}
+
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, p.errorf(tok, "unexpected %s", describe(tok))
+	}
+
+	return node, nil
+}
+
+type parser struct {
+	input  string
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) errorf(tok token, format string, args ...any) *SyntaxError {
+	return &SyntaxError{Query: p.input, Pos: tok.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// parseOr handles: and ("OR" and)*
+func (p *parser) parseOr() (Node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOr {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+// parseAnd handles: not (["AND"] not)*
+func (p *parser) parseAnd() (Node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch p.peek().kind {
+		case tokAnd:
+			p.next()
+		case tokWord, tokString, tokLParen, tokNot:
+			// Juxtaposed terms are an implicit AND
+		default:
+			return left, nil
+		}
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+}
+
+// parseNot handles: "NOT" not | primary
+func (p *parser) parseNot() (Node, error) {
+	if p.peek().kind == tokNot {
+		p.next()
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+// parsePrimary handles: "(" or ")" | field ":" value | field op number | value
+func (p *parser) parsePrimary() (Node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokLParen:
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.peek(); closing.kind != tokRParen {
+			return nil, p.errorf(closing, "expected ')' to close '(' at column %d", utf8.RuneCountInString(p.input[:tok.pos])+1)
+		}
+		p.next()
+		return node, nil
+
+	case tokString:
+		return newTermNode(tok.text), nil
+
+	case tokWord:
+		switch p.peek().kind {
+		case tokColon, tokCompare:
+			return p.parseField(tok)
+		}
+		return newTermNode(tok.text), nil
+
+	default:
+		return nil, p.errorf(tok, "expected a search term but found %s", describe(tok))
+	}
+}
+
+func (p *parser) parseField(field token) (Node, error) {
+	name := strings.ToLower(field.text)
+	op := p.next()
+	value := p.next()
+	if value.kind != tokWord && value.kind != tokString {
+		return nil, p.errorf(value, "expected a value after %s%s", field.text, op.text)
+	}
+
+	if get, ok := numericFields[name]; ok {
+		operator := op.text
+		if op.kind == tokColon {
+			operator = "="
+		}
+		number, err := strconv.ParseFloat(value.text, 64)
+		if err != nil {
+			return nil, p.errorf(value, "%s expects a number, got %q", field.text, value.text)
+		}
+		return compareNode{field: name, get: get, op: operator, value: number}, nil
+	}
+
+	if _, ok := textFields[name]; ok {
+		if op.kind == tokCompare {
+			return nil, p.errorf(op, "%s is a text field and cannot be compared with %s", field.text, op.text)
+		}
+		return newFieldTermNode(name, value.text), nil
+	}
+
+	return nil, p.errorf(field, "unknown field %q", field.text)
+}
+
+func describe(tok token) string {
+	if tok.kind == tokWord || tok.kind == tokCompare {
+		return fmt.Sprintf("%q", tok.text)
+	}
+	return tok.kind.String()
+}
+
+type andNode struct{ left, right Node }
+
+func (n andNode) Match(doc *Document) bool { return n.left.Match(doc) && n.right.Match(doc) }
+func (n andNode) String() string           { return "(" + n.left.String() + " AND " + n.right.String() + ")" }
+
+type orNode struct{ left, right Node }
+
+func (n orNode) Match(doc *Document) bool { return n.left.Match(doc) || n.right.Match(doc) }
+func (n orNode) String() string           { return "(" + n.left.String() + " OR " + n.right.String() + ")" }
+
+type notNode struct{ operand Node }
+
+func (n notNode) Match(doc *Document) bool { return !n.operand.Match(doc) }
+func (n notNode) String() string           { return "NOT " + n.operand.String() }
+
+// termNode matches folded text in one field, or in every field of
+// anyFields when name is empty
+type termNode struct {
+	name   string
+	fields []fieldIndex
+	value  string
+}
+
+func newTermNode(value string) termNode {
+	return termNode{fields: anyFields, value: textnorm.Fold(value)}
+}
+
+func newFieldTermNode(name, value string) termNode {
+	return termNode{name: name, fields: []fieldIndex{textFields[name]}, value: textnorm.Fold(value)}
+}
+
+func (n termNode) Match(doc *Document) bool {
+	for _, f := range n.fields {
+		if strings.Contains(doc.text[f], n.value) {
+			return true
+		}
+	}
+	return false
+}
+
+func (n termNode) String() string {
+	if n.name == "" {
+		return strconv.Quote(n.value)
+	}
+	return n.name + ":" + strconv.Quote(n.value)
+}
+
+type compareNode struct {
+	field string
+	get   func(models.MedicineRecord) float64
+	op    string
+	value float64
+}
+
+func (n compareNode) Match(doc *Document) bool {
+	v := n.get(doc.Record)
+	switch n.op {
+	case ">":
+		return v > n.value
+	case ">=":
+		return v >= n.value
+	case "<":
+		return v < n.value
+	case "<=":
+		return v <= n.value
+	default:
+		return v == n.value
+	}
+}
+
+func (n compareNode) String() string {
+	return n.field + n.op + strconv.FormatFloat(n.value, 'g', -1, 64)
+}
@@ -0,0 +1,199 @@
+package query
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Nexusrex18/medCli/internal/models"
+)
+
+func TestLex(t *testing.T) {
+	tests := []struct {
+		input string
+		kinds []tokenKind
+		texts []string
+	}{
+		{"", []tokenKind{tokEOF}, []string{""}},
+		{"fever", []tokenKind{tokWord, tokEOF}, []string{"fever", ""}},
+		{`title:"kapha dosha"`, []tokenKind{tokWord, tokColon, tokString, tokEOF}, []string{"title", ":", "kapha dosha", ""}},
+		{"confidence>=0.8", []tokenKind{tokWord, tokCompare, tokWord, tokEOF}, []string{"confidence", ">=", "0.8", ""}},
+		{"confidence=1", []tokenKind{tokWord, tokCompare, tokWord, tokEOF}, []string{"confidence", "=", "1", ""}},
+		{"(a OR b)", []tokenKind{tokLParen, tokWord, tokOr, tokWord, tokRParen, tokEOF}, []string{"(", "a", "OR", "b", ")", ""}},
+		{"NOT a AND b", []tokenKind{tokNot, tokWord, tokAnd, tokWord, tokEOF}, []string{"NOT", "a", "AND", "b", ""}},
+		// Keywords are only recognised in upper case
+		{"a and not b Or c", []tokenKind{tokWord, tokWord, tokWord, tokWord, tokWord, tokWord, tokEOF}, []string{"a", "and", "not", "b", "Or", "c", ""}},
+		{`""`, []tokenKind{tokString, tokEOF}, []string{"", ""}},
+		{"jvara\tज्वर", []tokenKind{tokWord, tokWord, tokEOF}, []string{"jvara", "ज्वर", ""}},
+	}
+
+	for _, tt := range tests {
+		tokens, err := lex(tt.input)
+		if err != nil {
+			t.Errorf("lex(%q) failed: %v", tt.input, err)
+			continue
+		}
+		var kinds []tokenKind
+		var texts []string
+		for _, tok := range tokens {
+			kinds = append(kinds, tok.kind)
+			texts = append(texts, tok.text)
+		}
+		if !reflect.DeepEqual(kinds, tt.kinds) || !reflect.DeepEqual(texts, tt.texts) {
+			t.Errorf("lex(%q) = %v %q, want %v %q", tt.input, kinds, texts, tt.kinds, tt.texts)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"fever", `"fever"`},
+		{"Fever Chills", `("fever" AND "chills")`},
+		{"a OR b c", `("a" OR ("b" AND "c"))`},
+		{"(a OR b) c", `(("a" OR "b") AND "c")`},
+		{"a AND NOT b", `("a" AND NOT "b")`},
+		{"NOT NOT a", `NOT NOT "a"`},
+		{"a and b", `(("a" AND "and") AND "b")`},
+		{`title:"Kapha Dosha"`, `title:"kapha dosha"`},
+		{"TM2:SP00", `tm2:"sp00"`},
+		{"type:Unani (fever OR humma)", `(type:"unani" AND ("fever" OR "humma"))`},
+		{"confidence>0.8", "confidence>0.8"},
+		{"confidence:1", "confidence=1"},
+		{"confidence_score<=0.25", "confidence_score<=0.25"},
+		{"jvāra", `"jvara"`},
+		{`""`, `""`},
+	}
+
+	for _, tt := range tests {
+		node, err := Parse(tt.input)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", tt.input, err)
+			continue
+		}
+		if got := node.String(); got != tt.want {
+			t.Errorf("Parse(%q) = %s, want %s", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		input  string
+		column int
+		msg    string
+		caret  string
+	}{
+		{"", 1, "empty query", "\n^"},
+		{"   ", 4, "empty query", "   \n   ^"},
+		{`title:"kapha`, 7, "unterminated quoted string", "title:\"kapha\n      ^"},
+		{"(fever", 7, "expected ')' to close '(' at column 1", "(fever\n      ^"},
+		{"fever)", 6, "unexpected ')'", "fever)\n     ^"},
+		{"fever AND", 10, "expected a search term but found end of query", "fever AND\n         ^"},
+		{"OR fever", 1, "expected a search term but found OR", "OR fever\n^"},
+		{"colour:red", 1, `unknown field "colour"`, "colour:red\n^"},
+		{"title>3", 6, "title is a text field and cannot be compared with >", "title>3\n     ^"},
+		{"confidence>high", 12, `confidence expects a number, got "high"`, "confidence>high\n           ^"},
+		{"title:", 7, "expected a value after title:", "title:\n      ^"},
+		// Columns count runes, not bytes
+		{"ज्वर )", 6, "unexpected ')'", "ज्वर )\n     ^"},
+	}
+
+	for _, tt := range tests {
+		_, err := Parse(tt.input)
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Errorf("Parse(%q) error = %v, want a *SyntaxError", tt.input, err)
+			continue
+		}
+		if syntaxErr.Column() != tt.column || syntaxErr.Msg != tt.msg {
+			t.Errorf("Parse(%q) error at column %d: %s, want column %d: %s", tt.input, syntaxErr.Column(), syntaxErr.Msg, tt.column, tt.msg)
+		}
+		if got := syntaxErr.Caret(); got != tt.caret {
+			t.Errorf("Parse(%q) caret = %q, want %q", tt.input, got, tt.caret)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	doc := NewDocument(models.MedicineRecord{
+		TM2Code:         "SP00",
+		Code:            "AYU-001",
+		TM2Title:        "Fever disorder (TM2)",
+		TM2Definition:   "A disorder with raised body temperature and chills",
+		CodeTitle:       "Jvāra",
+		Description:     "Fever with headache and thirst",
+		Type:            "Ayurveda",
+		ConfidenceScore: 0.8,
+	})
+
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{"fever", true},
+		{"FEVER", true},
+		{"jvara", true},
+		{"cough", false},
+		{"fever chills", true},
+		{"fever cough", false},
+		{"fever OR cough", true},
+		{"NOT cough", true},
+		{"fever AND NOT chills", false},
+		// Lower-case keywords are searched for as words
+		{"temperature and chills", true},
+		{"cough or fever", false},
+		{"definition:chills", true},
+		{"title:chills", false},
+		{`title:"fever disorder"`, true},
+		{`title:"disorder fever"`, false},
+		{"code:ayu-001", true},
+		{"tm2:sp00 type:ayurveda", true},
+		{"type:Unani", false},
+		{"confidence>0.8", false},
+		{"confidence>=0.8", true},
+		{"confidence=0.8", true},
+		{"confidence<0.5", false},
+		// An empty phrase is contained in every text, so it matches everything
+		{`""`, true},
+		{`title:""`, true},
+		{`NOT ""`, false},
+	}
+
+	for _, tt := range tests {
+		node, err := Parse(tt.input)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", tt.input, err)
+			continue
+		}
+		if got := node.Match(&doc); got != tt.want {
+			t.Errorf("%s matched = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestLooksLikeQuery(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{"fever", false},
+		{"fever, joint pain", false},
+		{"fever and chills", false},
+		{"fever AND chills", true},
+		{"NOT cough", true},
+		{"(fever)", true},
+		{"title:fever", true},
+		{"confidence>0.5", true},
+		{`"joint pain"`, true},
+		{`"unterminated`, true},
+	}
+
+	for _, tt := range tests {
+		if got := LooksLikeQuery(tt.input); got != tt.want {
+			t.Errorf("LooksLikeQuery(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
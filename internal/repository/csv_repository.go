@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"encoding/csv"
 	"fmt"
 	"os"
@@ -8,30 +9,47 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/Nexusrex18/medCli/internal/analysis"
 	"github.com/Nexusrex18/medCli/internal/models"
+	"github.com/Nexusrex18/medCli/internal/query"
+	"github.com/Nexusrex18/medCli/internal/synonyms"
+	"github.com/Nexusrex18/medCli/internal/textnorm"
 )
 
 type CSVRepository struct {
 	records      []models.MedicineRecord
 	codeIndex    map[string][]models.MedicineRecord // code -> records
 	tm2CodeIndex map[string][]models.MedicineRecord // tm2_code -> records
+	searchText   []string                           // folded searchable text, aligned with records
+	documents    []query.Document                   // folded fields for the query language, aligned with records
+	terms        []map[string]bool                  // analysed terms of searchText, aligned with records
+	similarity   *similarityIndex                   // TF-IDF vectors of the analysed terms
+	analyzer     *analysis.Analyzer
+	synonyms     *synonyms.Dictionary
 	mu           sync.RWMutex
 }
 
-func NewCSVRepository(csvFilePath string) (*CSVRepository, error) {
+// NewCSVRepository loads the CSV file and indexes it with analyzer
+func NewCSVRepository(csvFilePath string, analyzer *analysis.Analyzer) (*CSVRepository, error) {
 	repo := &CSVRepository{
-		codeIndex:    make(map[string][]models.MedicineRecord),
-		tm2CodeIndex: make(map[string][]models.MedicineRecord),
+		analyzer: analyzer,
 	}
 
-	if err := repo.loadCSV(csvFilePath); err != nil {
+	if err := repo.loadCSV(csvFilePath, nil); err != nil {
 		return nil, err
 	}
 
 	return repo, nil
 }
 
-func (r *CSVRepository) loadCSV(filePath string) error {
+// Reload re-reads the CSV file and rebuilds every index, with analyzer
+// unless it is nil. The current data and analyzer are kept when the file
+// cannot be loaded.
+func (r *CSVRepository) Reload(csvFilePath string, analyzer *analysis.Analyzer) error {
+	return r.loadCSV(csvFilePath, analyzer)
+}
+
+func (r *CSVRepository) loadCSV(filePath string, analyzer *analysis.Analyzer) error {
 	file, err := os.Open(filePath)
 	if err != nil {
 		return fmt.Errorf("failed to open CSV file: %w", err)
@@ -89,13 +107,24 @@ func (r *CSVRepository) loadCSV(filePath string) error {
 	defer r.mu.Unlock()
 
 	r.records = dataRecords
+	if analyzer != nil {
+		r.analyzer = analyzer
+	}
 	r.buildIndexes()
 
 	return nil
 }
 
 func (r *CSVRepository) buildIndexes() {
-	for _, record := range r.records {
+	r.codeIndex = make(map[string][]models.MedicineRecord)
+	r.tm2CodeIndex = make(map[string][]models.MedicineRecord)
+	r.searchText = make([]string, len(r.records))
+	r.documents = make([]query.Document, len(r.records))
+	for i, record := range r.records {
+		// Fold diacritics and Indic scripts so every spelling of a term matches
+		r.searchText[i] = textnorm.Fold(searchableText(record))
+		r.documents[i] = query.NewDocument(record)
+
 		// Index by traditional code (lowercase for case-insensitive search)
 		codeKey := strings.ToLower(record.Code)
 		r.codeIndex[codeKey] = append(r.codeIndex[codeKey], record)
@@ -104,9 +133,46 @@ func (r *CSVRepository) buildIndexes() {
 		tm2Key := strings.ToLower(record.TM2Code)
 		r.tm2CodeIndex[tm2Key] = append(r.tm2CodeIndex[tm2Key], record)
 	}
+
+	r.buildTerms()
 }
 
-func (r *CSVRepository) SearchByCode(code string) []models.MedicineRecord {
+// buildTerms runs every record through the analyzer so symptom searches
+// can match stemmed terms and similar records can be ranked. Similarity
+// only looks at the TM2 concept, its title and definition.
+func (r *CSVRepository) buildTerms() {
+	r.terms = make([]map[string]bool, len(r.records))
+	concepts := make([][]string, len(r.records))
+	for i, record := range r.records {
+		concepts[i] = r.analyzer.Terms(conceptText(record))
+		mapping := r.analyzer.Terms(record.Description + " " + record.CodeTitle)
+		r.terms[i] = make(map[string]bool, len(concepts[i])+len(mapping))
+		for _, term := range concepts[i] {
+			r.terms[i][term] = true
+		}
+		for _, term := range mapping {
+			r.terms[i][term] = true
+		}
+	}
+	r.similarity = buildSimilarityIndex(concepts)
+}
+
+// searchableText combines the fields a symptom search looks in
+func searchableText(record models.MedicineRecord) string {
+	return record.TM2Title + " " +
+		record.Description + " " +
+		record.TM2Definition + " " +
+		record.CodeTitle
+}
+
+// conceptText combines the fields similar records are compared on
+func conceptText(record models.MedicineRecord) string {
+	return record.TM2Title + " " + record.TM2Definition
+}
+
+// SearchByCode looks the code up in both indexes and returns the page of
+// matches selected by opts along with the total match count
+func (r *CSVRepository) SearchByCode(code string, opts SearchOptions) ([]models.MedicineRecord, int) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
@@ -121,70 +187,171 @@ func (r *CSVRepository) SearchByCode(code string) []models.MedicineRecord {
 		results = append(results, records...)
 	}
 
-	return results
+	return paginate(opts, results, identity)
 }
 
-func (r *CSVRepository) SearchBySymptoms(symptoms []string) []models.MedicineRecord {
+// SearchByQuery returns the page of records matched by a parsed query
+// along with the total match count. The scan stops with ctx's error once
+// ctx is done.
+func (r *CSVRepository) SearchByQuery(ctx context.Context, q query.Node, opts SearchOptions) ([]models.MedicineRecord, int, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	var results []models.MedicineRecord
-	seen := make(map[string]bool) // To avoid duplicates
+	for i := range r.documents {
+		if err := checkCancelled(ctx, i); err != nil {
+			return nil, 0, err
+		}
+		if q.Match(&r.documents[i]) {
+			results = append(results, r.records[i])
+		}
+	}
 
-	for _, record := range r.records {
-		// Combine all searchable fields into one string for this record
-		searchableText := strings.ToLower(
-			record.TM2Title + " " +
-				record.Description + " " +
-				record.TM2Definition + " " +
-				record.CodeTitle,
-		)
-
-		// Check if this record matches ALL symptoms (AND logic)
-		recordMatchesAll := true
-
-		for _, symptom := range symptoms {
-			symptom = strings.ToLower(strings.TrimSpace(symptom))
-			if symptom == "" {
-				continue
-			}
+	page, total := paginate(opts, results, identity)
+	return page, total, nil
+}
 
-			// Split symptom into individual words
-			words := strings.Fields(symptom)
-			if len(words) == 0 {
-				continue
-			}
+// scanCheckInterval is how many records a full scan visits between checks
+// for cancellation
+const scanCheckInterval = 256
 
-			// Check if ALL words in this symptom exist in the searchable text
-			allWordsFound := true
-			for _, word := range words {
-				if len(word) < 2 { // Skip very short words
-					continue
-				}
-				if !strings.Contains(searchableText, word) {
-					allWordsFound = false
-					break
-				}
-			}
+// checkCancelled returns ctx's error every scanCheckInterval records
+func checkCancelled(ctx context.Context, i int) error {
+	if i%scanCheckInterval != 0 {
+		return nil
+	}
+	return ctx.Err()
+}
 
-			// If any symptom doesn't match all its words, this record fails
-			if !allWordsFound {
-				recordMatchesAll = false
-				break
-			}
-		}
+// GroupByTM2Code returns every traditional code that maps to the given TM2 code
+func (r *CSVRepository) GroupByTM2Code(tm2Code string) *models.MappingGroup {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 
-		// If record matches all symptoms, add it to results
-		if recordMatchesAll && len(symptoms) > 0 {
-			key := record.TM2Code + ":" + record.Code
-			if !seen[key] {
-				results = append(results, record)
-				seen[key] = true
-			}
+	records, exists := r.tm2CodeIndex[strings.ToLower(strings.TrimSpace(tm2Code))]
+	if !exists {
+		return nil
+	}
+
+	group := &models.MappingGroup{
+		Code:   records[0].TM2Code,
+		System: "tm2",
+		Title:  records[0].TM2Title,
+	}
+	for _, record := range records {
+		group.Mappings = append(group.Mappings, models.Mapping{
+			Record:      record,
+			Cardinality: r.cardinalityOf(record),
+		})
+	}
+
+	return group
+}
+
+// GroupByCode returns every TM2 code that the given traditional code maps to
+func (r *CSVRepository) GroupByCode(code string) *models.MappingGroup {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	records, exists := r.codeIndex[strings.ToLower(strings.TrimSpace(code))]
+	if !exists {
+		return nil
+	}
+
+	group := &models.MappingGroup{
+		Code:   records[0].Code,
+		System: "traditional",
+		Title:  records[0].CodeTitle,
+	}
+	for _, record := range records {
+		group.Mappings = append(group.Mappings, models.Mapping{
+			Record:      record,
+			Cardinality: r.cardinalityOf(record),
+		})
+	}
+
+	return group
+}
+
+// CardinalityReport counts the distinct traditional -> TM2 pairs in the
+// dataset by cardinality label
+func (r *CSVRepository) CardinalityReport() map[models.Cardinality]int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.cardinalityReport()
+}
+
+func (r *CSVRepository) cardinalityReport() map[models.Cardinality]int {
+	report := make(map[models.Cardinality]int, len(models.Cardinalities))
+	for _, c := range models.Cardinalities {
+		report[c] = 0
+	}
+
+	seen := make(map[string]bool)
+	for _, record := range r.records {
+		key := strings.ToLower(record.Code) + ":" + strings.ToLower(record.TM2Code)
+		if seen[key] {
+			continue
 		}
+		seen[key] = true
+		report[r.cardinalityOf(record)]++
 	}
 
-	return results
+	return report
+}
+
+// cardinalityOf labels a record from the number of distinct TM2 codes its
+// traditional code maps to and the number of distinct traditional codes
+// that map to its TM2 code. Callers must hold the read lock.
+func (r *CSVRepository) cardinalityOf(record models.MedicineRecord) models.Cardinality {
+	tm2Targets := distinctCount(r.codeIndex[strings.ToLower(record.Code)], func(m models.MedicineRecord) string {
+		return m.TM2Code
+	})
+	codeSources := distinctCount(r.tm2CodeIndex[strings.ToLower(record.TM2Code)], func(m models.MedicineRecord) string {
+		return m.Code
+	})
+
+	switch {
+	case tm2Targets <= 1 && codeSources <= 1:
+		return models.OneToOne
+	case codeSources <= 1:
+		return models.OneToMany
+	case tm2Targets <= 1:
+		return models.ManyToOne
+	default:
+		return models.ManyToMany
+	}
+}
+
+func distinctCount(records []models.MedicineRecord, key func(models.MedicineRecord) string) int {
+	seen := make(map[string]bool)
+	for _, record := range records {
+		seen[strings.ToLower(key(record))] = true
+	}
+	return len(seen)
+}
+
+// FindRecord returns the mapping between tm2Code and code
+func (r *CSVRepository) FindRecord(tm2Code, code string) (models.MedicineRecord, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if i := r.indexOf(tm2Code, code); i >= 0 {
+		return r.records[i], true
+	}
+	return models.MedicineRecord{}, false
+}
+
+// indexOf returns the position of a mapping in records, or -1. Callers
+// must hold the read lock.
+func (r *CSVRepository) indexOf(tm2Code, code string) int {
+	for i, record := range r.records {
+		if strings.EqualFold(record.TM2Code, tm2Code) && strings.EqualFold(record.Code, code) {
+			return i
+		}
+	}
+	return -1
 }
 
 func (r *CSVRepository) GetAllRecords() []models.MedicineRecord {
@@ -197,9 +364,16 @@ func (r *CSVRepository) GetStats() map[string]int {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	return map[string]int{
+	stats := map[string]int{
 		"total_records":    len(r.records),
 		"unique_codes":     len(r.codeIndex),
 		"unique_tm2_codes": len(r.tm2CodeIndex),
+		"indexed_terms":    len(r.similarity.postings),
+		"term_postings":    r.similarity.size(),
 	}
+	for cardinality, count := range r.cardinalityReport() {
+		stats[string(cardinality)] = count
+	}
+
+	return stats
 }
\ No newline at end of file
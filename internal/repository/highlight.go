@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/Nexusrex18/medCli/internal/models"
+	"github.com/Nexusrex18/medCli/internal/textnorm"
+)
+
+// searchedFields are the record fields a symptom search looks in, keyed
+// by their JSON names
+var searchedFields = []struct {
+	name  string
+	value func(models.MedicineRecord) string
+}{
+	{"tm2_title", func(r models.MedicineRecord) string { return r.TM2Title }},
+	{"code_title", func(r models.MedicineRecord) string { return r.CodeTitle }},
+	{"tm2_definition", func(r models.MedicineRecord) string { return r.TM2Definition }},
+	{"code_description", func(r models.MedicineRecord) string { return r.Description }},
+}
+
+// highlight locates the matched terms in the searched fields of a record
+// and returns the spans as byte offsets into the original field values.
+// A word is highlighted when its stem matches a matched term, and a
+// matched term inside a longer word is highlighted on its own. Overlapping
+// spans in a field are merged.
+func (r *CSVRepository) highlight(record models.MedicineRecord, terms []models.TermMatch) []models.Highlight {
+	stems := make(map[string]bool)
+	var words []string
+	for _, term := range terms {
+		matched := term.Term
+		if term.Synonym != "" {
+			matched = term.Synonym
+		}
+		for _, token := range r.analyzer.Analyze(matched) {
+			stems[token.Term] = true
+			words = append(words, token.Text)
+		}
+	}
+	if len(words) == 0 {
+		return nil
+	}
+
+	var highlights []models.Highlight
+	for _, field := range searchedFields {
+		value := field.value(record)
+
+		var spans []models.Highlight
+		for _, token := range r.analyzer.Analyze(value) {
+			if stems[token.Term] {
+				spans = append(spans, models.Highlight{Field: field.name, Start: token.Start, End: token.End})
+			}
+		}
+
+		folded, offsets := textnorm.FoldMap(value)
+		for _, word := range words {
+			for from := 0; from < len(folded); {
+				i := strings.Index(folded[from:], word)
+				if i < 0 {
+					break
+				}
+				start := from + i
+				end := start + len(word)
+				if offsets[end] > offsets[start] {
+					spans = append(spans, models.Highlight{Field: field.name, Start: offsets[start], End: offsets[end]})
+				}
+				from = end
+			}
+		}
+
+		highlights = append(highlights, mergeSpans(spans)...)
+	}
+
+	return highlights
+}
+
+func mergeSpans(spans []models.Highlight) []models.Highlight {
+	if len(spans) < 2 {
+		return spans
+	}
+
+	sort.Slice(spans, func(i, j int) bool { return spans[i].Start < spans[j].Start })
+	merged := spans[:1]
+	for _, span := range spans[1:] {
+		last := &merged[len(merged)-1]
+		if span.Start <= last.End {
+			if span.End > last.End {
+				last.End = span.End
+			}
+			continue
+		}
+		merged = append(merged, span)
+	}
+	return merged
+}
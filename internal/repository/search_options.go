@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Nexusrex18/medCli/internal/models"
+)
+
+type SortKey string
+
+const (
+	SortNone       SortKey = ""
+	SortConfidence SortKey = "confidence"
+	SortCode       SortKey = "code"
+	SortTitle      SortKey = "title"
+)
+
+// SearchOptions selects one page of a sorted result set. A zero Limit
+// returns everything from Offset onwards and an empty SortBy keeps the
+// dataset order.
+type SearchOptions struct {
+	Offset     int     `json:"offset"`
+	Limit      int     `json:"limit"`
+	SortBy     SortKey `json:"sort_by"`
+	Descending bool    `json:"descending"`
+}
+
+func (o SearchOptions) Validate() error {
+	if o.Offset < 0 {
+		return fmt.Errorf("offset must not be negative, got %d", o.Offset)
+	}
+	if o.Limit < 0 {
+		return fmt.Errorf("limit must not be negative, got %d", o.Limit)
+	}
+	switch o.SortBy {
+	case SortNone, SortConfidence, SortCode, SortTitle:
+		return nil
+	default:
+		return fmt.Errorf("unknown sort key %q (use confidence, code or title)", o.SortBy)
+	}
+}
+
+// ParseSortKey maps user input onto a SortKey, accepting any case
+func ParseSortKey(s string) (SortKey, error) {
+	key := SortKey(strings.ToLower(strings.TrimSpace(s)))
+	if err := (SearchOptions{SortBy: key}).Validate(); err != nil {
+		return SortNone, err
+	}
+	return key, nil
+}
+
+// paginate sorts items in place and returns the requested page along
+// with the total number of items before paging. record exposes the
+// MedicineRecord each item is sorted by.
+func paginate[T any](o SearchOptions, items []T, record func(T) models.MedicineRecord) ([]T, int) {
+	total := len(items)
+
+	if compare := o.compare(); compare != nil {
+		sort.SliceStable(items, func(i, j int) bool {
+			if o.Descending {
+				return compare(record(items[i]), record(items[j])) > 0
+			}
+			return compare(record(items[i]), record(items[j])) < 0
+		})
+	}
+
+	if o.Offset >= total {
+		return []T{}, total
+	}
+	end := total
+	if o.Limit > 0 && o.Offset+o.Limit < total {
+		end = o.Offset + o.Limit
+	}
+
+	return items[o.Offset:end], total
+}
+
+func (o SearchOptions) compare() func(a, b models.MedicineRecord) int {
+	switch o.SortBy {
+	case SortConfidence:
+		return func(a, b models.MedicineRecord) int {
+			switch {
+			case a.ConfidenceScore < b.ConfidenceScore:
+				return -1
+			case a.ConfidenceScore > b.ConfidenceScore:
+				return 1
+			}
+			return 0
+		}
+	case SortCode:
+		return func(a, b models.MedicineRecord) int {
+			if c := strings.Compare(strings.ToLower(a.TM2Code), strings.ToLower(b.TM2Code)); c != 0 {
+				return c
+			}
+			return strings.Compare(strings.ToLower(a.Code), strings.ToLower(b.Code))
+		}
+	case SortTitle:
+		return func(a, b models.MedicineRecord) int {
+			return strings.Compare(strings.ToLower(a.TM2Title), strings.ToLower(b.TM2Title))
+		}
+	default:
+		return nil
+	}
+}
+
+func identity(record models.MedicineRecord) models.MedicineRecord {
+	return record
+}
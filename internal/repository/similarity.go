@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/Nexusrex18/medCli/internal/models"
+)
+
+// similarityIndex holds unit-length TF-IDF vectors for every record and
+// an inverted index over them, so cosine similarity against one record is
+// a walk over the postings of its terms
+type similarityIndex struct {
+	vectors  []map[string]float64 // aligned with records
+	postings map[string][]posting
+}
+
+type posting struct {
+	record int
+	weight float64
+}
+
+// buildSimilarityIndex weights each document's terms by TF-IDF, using a
+// log-scaled term frequency and a smoothed inverse document frequency
+func buildSimilarityIndex(docs [][]string) *similarityIndex {
+	df := make(map[string]int)
+	counts := make([]map[string]int, len(docs))
+	for i, terms := range docs {
+		counts[i] = make(map[string]int)
+		for _, term := range terms {
+			counts[i][term]++
+		}
+		for term := range counts[i] {
+			df[term]++
+		}
+	}
+
+	index := &similarityIndex{
+		vectors:  make([]map[string]float64, len(docs)),
+		postings: make(map[string][]posting),
+	}
+	n := float64(len(docs))
+	for i, termCounts := range counts {
+		vector := make(map[string]float64, len(termCounts))
+		var norm float64
+		for term, count := range termCounts {
+			weight := (1 + math.Log(float64(count))) * math.Log(1+n/float64(df[term]))
+			vector[term] = weight
+			norm += weight * weight
+		}
+		norm = math.Sqrt(norm)
+		if norm == 0 {
+			index.vectors[i] = vector
+			continue
+		}
+		for term, weight := range vector {
+			vector[term] = weight / norm
+			index.postings[term] = append(index.postings[term], posting{record: i, weight: vector[term]})
+		}
+		index.vectors[i] = vector
+	}
+
+	return index
+}
+
+// size is the number of postings across every term
+func (s *similarityIndex) size() int {
+	n := 0
+	for _, postings := range s.postings {
+		n += len(postings)
+	}
+	return n
+}
+
+// scores returns the cosine similarity of record i to every record that
+// shares at least one term with it
+func (s *similarityIndex) scores(i int) map[int]float64 {
+	scores := make(map[int]float64)
+	for term, weight := range s.vectors[i] {
+		for _, p := range s.postings[term] {
+			scores[p.record] += weight * p.weight
+		}
+	}
+	return scores
+}
+
+// SimilarTo ranks the other TM2 concepts by how close their title and
+// definition text is to the record identified by tm2Code and code. Each
+// TM2 code appears once, with the score of its closest record. It returns
+// the page selected by opts, the total number of similar concepts, and
+// false when the record does not exist.
+func (r *CSVRepository) SimilarTo(tm2Code, code string, opts SearchOptions) ([]models.ScoredRecord, int, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	source := r.indexOf(tm2Code, code)
+	if source < 0 {
+		return nil, 0, false
+	}
+
+	best := make(map[string]models.ScoredRecord)
+	for i, score := range r.similarity.scores(source) {
+		record := r.records[i]
+		key := strings.ToLower(record.TM2Code)
+		if key == strings.ToLower(tm2Code) || score <= 0 {
+			continue
+		}
+		if current, ok := best[key]; !ok || score > current.Score {
+			best[key] = models.ScoredRecord{Record: record, Score: math.Min(score, 1)}
+		}
+	}
+
+	results := make([]models.ScoredRecord, 0, len(best))
+	for _, scored := range best {
+		results = append(results, scored)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Record.TM2Code < results[j].Record.TM2Code
+	})
+
+	// Results are always ranked by score, so only the paging options apply
+	page, total := paginate(SearchOptions{Offset: opts.Offset, Limit: opts.Limit}, results, func(s models.ScoredRecord) models.MedicineRecord {
+		return s.Record
+	})
+	return page, total, true
+}
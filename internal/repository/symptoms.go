@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Nexusrex18/medCli/internal/analysis"
+	"github.com/Nexusrex18/medCli/internal/models"
+	"github.com/Nexusrex18/medCli/internal/synonyms"
+	"github.com/Nexusrex18/medCli/internal/textnorm"
+)
+
+// analyzedSymptom is one symptom from a query, run through the analyzer
+// once up front together with its synonym expansions
+type analyzedSymptom struct {
+	text     string
+	tokens   []analysis.Token
+	synonyms []analyzedPhrase   // synonyms of the whole symptom
+	words    [][]analyzedPhrase // synonyms of each token, aligned with tokens
+}
+
+type analyzedPhrase struct {
+	text   string
+	tokens []analysis.Token
+}
+
+// SearchBySymptoms returns the page of records matching every symptom
+// along with the total match count. Symptoms and their words are expanded
+// through the synonym dictionary, and each match records the terms that
+// satisfied it and the field offsets where they were found. The scan stops
+// with ctx's error once ctx is done.
+func (r *CSVRepository) SearchBySymptoms(ctx context.Context, symptoms []string, opts SearchOptions) ([]models.SymptomMatch, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var analyzed []analyzedSymptom
+	for _, symptom := range symptoms {
+		symptom = textnorm.Fold(strings.TrimSpace(symptom))
+		if symptom == "" {
+			continue
+		}
+		analyzed = append(analyzed, r.analyzeSymptom(symptom))
+	}
+
+	var results []models.SymptomMatch
+	seen := make(map[string]bool) // To avoid duplicates
+
+	for i, record := range r.records {
+		if err := checkCancelled(ctx, i); err != nil {
+			return nil, 0, err
+		}
+
+		// Check if this record matches ALL symptoms (AND logic)
+		recordMatchesAll := true
+		var terms []models.TermMatch
+
+		for _, symptom := range analyzed {
+			matched, ok := r.matchSymptom(i, symptom)
+			if !ok {
+				// If any symptom doesn't match, this record fails
+				recordMatchesAll = false
+				break
+			}
+			terms = append(terms, matched...)
+		}
+
+		// If record matches all symptoms, add it to results
+		if recordMatchesAll && len(symptoms) > 0 {
+			key := record.TM2Code + ":" + record.Code
+			if !seen[key] {
+				results = append(results, models.SymptomMatch{Record: record, Terms: terms})
+				seen[key] = true
+			}
+		}
+	}
+
+	page, total := paginate(opts, results, func(m models.SymptomMatch) models.MedicineRecord {
+		return m.Record
+	})
+
+	// Highlights are only worked out for the records actually returned
+	for i := range page {
+		page[i].Highlights = r.highlight(page[i].Record, page[i].Terms)
+	}
+
+	return page, total, nil
+}
+
+func (r *CSVRepository) analyzeSymptom(symptom string) analyzedSymptom {
+	analyzed := analyzedSymptom{
+		text:   symptom,
+		tokens: r.analyzer.Analyze(symptom),
+	}
+	for _, synonym := range r.synonyms.Expand(symptom) {
+		analyzed.synonyms = append(analyzed.synonyms, analyzedPhrase{synonym, r.analyzer.Analyze(synonym)})
+	}
+	analyzed.words = make([][]analyzedPhrase, len(analyzed.tokens))
+	for i, token := range analyzed.tokens {
+		for _, synonym := range r.synonyms.Expand(token.Text) {
+			analyzed.words[i] = append(analyzed.words[i], analyzedPhrase{synonym, r.analyzer.Analyze(synonym)})
+		}
+	}
+	return analyzed
+}
+
+// matchSymptom checks one symptom against record i. The whole phrase is
+// tried first, then its phrase-level synonyms, and finally each word on
+// its own or through one of its synonyms.
+func (r *CSVRepository) matchSymptom(i int, symptom analyzedSymptom) ([]models.TermMatch, bool) {
+	if len(symptom.tokens) == 0 {
+		// Nothing left after dropping stop words
+		return nil, true
+	}
+
+	if r.matchesAll(i, symptom.tokens) {
+		return []models.TermMatch{{Term: symptom.text}}, true
+	}
+	for _, synonym := range symptom.synonyms {
+		if r.matchesAll(i, synonym.tokens) {
+			return []models.TermMatch{{Term: symptom.text, Synonym: synonym.text}}, true
+		}
+	}
+
+	var terms []models.TermMatch
+	for w, token := range symptom.tokens {
+		if r.matches(i, token) {
+			terms = append(terms, models.TermMatch{Term: token.Text})
+			continue
+		}
+
+		found := false
+		for _, synonym := range symptom.words[w] {
+			if r.matchesAll(i, synonym.tokens) {
+				terms = append(terms, models.TermMatch{Term: token.Text, Synonym: synonym.text})
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, false
+		}
+	}
+
+	return terms, true
+}
+
+// matches reports whether a query token occurs in record i, either as an
+// analysed term or as part of a longer word in the folded text
+func (r *CSVRepository) matches(i int, token analysis.Token) bool {
+	return r.terms[i][token.Term] || strings.Contains(r.searchText[i], token.Text)
+}
+
+func (r *CSVRepository) matchesAll(i int, tokens []analysis.Token) bool {
+	if len(tokens) == 0 {
+		return false
+	}
+	for _, token := range tokens {
+		if !r.matches(i, token) {
+			return false
+		}
+	}
+	return true
+}
+
+// SetSynonyms replaces the dictionary used to expand symptom queries
+func (r *CSVRepository) SetSynonyms(dict *synonyms.Dictionary) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.synonyms = dict
+}
+
+// SetAnalyzer replaces the analysis pipeline and re-analyses every record
+func (r *CSVRepository) SetAnalyzer(analyzer *analysis.Analyzer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.analyzer = analyzer
+	r.buildTerms()
+}
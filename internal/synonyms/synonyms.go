@@ -0,0 +1,133 @@
+package synonyms
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Nexusrex18/medCli/internal/textnorm"
+)
+
+// defaultGroups are shipped with the binary. Each group lists terms that
+// describe the same condition across English, Sanskrit, Tamil and Urdu.
+var defaultGroups = [][]string{
+	{"fever", "pyrexia", "jvara", "jwara", "suram", "humma"},
+	{"cough", "kasa", "kaasa", "irumal", "sual", "suaal"},
+	{"headache", "shirashula", "shiroshula", "thalaivali", "suda", "sudaa"},
+	{"joint pain", "sandhishula", "sandhivata", "moottuvali", "waja ul mafasil"},
+	{"diarrhoea", "diarrhea", "atisara", "kazhichal", "ishal"},
+	{"vomiting", "chardi", "vanti", "qai"},
+	{"indigestion", "ajirna", "agnimandya", "su-e-hazm"},
+	{"constipation", "vibandha", "malabaddha", "qabz"},
+	{"breathlessness", "dyspnoea", "dyspnea", "shwasa", "swasam", "zeequn nafas"},
+	{"jaundice", "kamala", "manjal kamalai", "yarqan"},
+	{"insomnia", "anidra", "nidranasha", "sahar"},
+	{"obesity", "sthaulya", "medoroga", "siman"},
+	{"diabetes", "prameha", "madhumeha", "neerizhivu", "ziabetus"},
+	{"anaemia", "anemia", "pandu", "paandu", "faqr-ud-dam"},
+	{"dizziness", "giddiness", "vertigo", "bhrama", "dawar"},
+	{"burning sensation", "daha", "erichal", "sozish"},
+}
+
+// Dictionary maps a lowercased term to every other term it shares a
+// group with. A nil Dictionary expands nothing.
+type Dictionary struct {
+	terms map[string][]string
+}
+
+func New(groups [][]string) *Dictionary {
+	d := &Dictionary{terms: make(map[string][]string)}
+	for _, group := range groups {
+		d.Add(group)
+	}
+	return d
+}
+
+// Default returns a dictionary holding only the built-in groups
+func Default() *Dictionary {
+	return New(defaultGroups)
+}
+
+// Load returns the built-in groups merged with the groups in path. A
+// missing file is not an error so the defaults always apply.
+func Load(path string) (*Dictionary, error) {
+	d := Default()
+	if path == "" {
+		return d, nil
+	}
+
+	file, err := os.Open(os.ExpandEnv(path))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return d, nil
+		}
+		return nil, fmt.Errorf("failed to open synonyms file: %w", err)
+	}
+	defer file.Close()
+
+	// One group per line, terms separated by commas, '#' starts a comment
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		d.Add(strings.Split(line, ","))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read synonyms file: %w", err)
+	}
+
+	return d, nil
+}
+
+// Add records every term in group as a synonym of the others
+func (d *Dictionary) Add(group []string) {
+	var terms []string
+	for _, term := range group {
+		if term = normalize(term); term != "" {
+			terms = append(terms, term)
+		}
+	}
+	if len(terms) < 2 {
+		return
+	}
+
+	for _, term := range terms {
+		for _, other := range terms {
+			if other != term && !contains(d.terms[term], other) {
+				d.terms[term] = append(d.terms[term], other)
+			}
+		}
+	}
+}
+
+// Expand returns the synonyms of term, not including term itself
+func (d *Dictionary) Expand(term string) []string {
+	if d == nil {
+		return nil
+	}
+	return d.terms[normalize(term)]
+}
+
+// Len reports how many distinct terms the dictionary knows
+func (d *Dictionary) Len() int {
+	if d == nil {
+		return 0
+	}
+	return len(d.terms)
+}
+
+func normalize(term string) string {
+	return strings.Join(strings.Fields(textnorm.Fold(term)), " ")
+}
+
+func contains(terms []string, term string) bool {
+	for _, t := range terms {
+		if t == term {
+			return true
+		}
+	}
+	return false
+}
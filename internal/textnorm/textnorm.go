@@ -0,0 +1,183 @@
+// Package textnorm folds the different ways traditional terms are written
+// into one comparable Latin form. IAST ("vāta"), plain ASCII ("vata"),
+// Devanagari ("वात") and Tamil script all fold to the same string.
+package textnorm
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Fold lowercases s, transliterates Devanagari and Tamil into Latin and
+// strips diacritics. ś and ṣ become "sh" because that is how they are
+// normally spelled in ASCII ("dosha", "shwasa").
+func Fold(s string) string {
+	folded, _ := FoldMap(s)
+	return folded
+}
+
+// FoldMap folds s like Fold and also returns, for every byte of the folded
+// string, the byte offset in s it was produced from. The offsets slice has
+// one extra entry holding len(s), so a folded span [i, j) maps back to
+// s[offsets[i]:offsets[j]].
+func FoldMap(s string) (string, []int) {
+	f := &folder{offsets: make([]int, 0, len(s)+1)}
+	f.b.Grow(len(s))
+
+	for pos := 0; pos < len(s); {
+		r, size := utf8.DecodeRuneInString(s[pos:])
+		next, _ := utf8.DecodeRuneInString(s[pos+size:])
+		if isIndic(r) {
+			f.transliterate(r, next, pos)
+		} else {
+			f.flush()
+			f.fold(r, pos)
+		}
+		pos += size
+	}
+	f.flush()
+
+	return f.b.String(), append(f.offsets, len(s))
+}
+
+type folder struct {
+	b            strings.Builder
+	offsets      []int
+	lastS        bool // the last byte written was a plain s that may take an "h"
+	pendingVowel bool // a consonant was written and still carries its inherent "a"
+	pendingPos   int  // source offset of that consonant
+}
+
+func (f *folder) write(text string, pos int) {
+	f.b.WriteString(text)
+	for i := 0; i < len(text); i++ {
+		f.offsets = append(f.offsets, pos)
+	}
+	f.lastS = false
+}
+
+func (f *folder) flush() {
+	if f.pendingVowel {
+		f.write("a", f.pendingPos)
+		f.pendingVowel = false
+	}
+}
+
+// fold lowercases one Latin (or other non-Indic) rune and drops its
+// combining marks, which carry the diacritics
+func (f *folder) fold(r rune, pos int) {
+	for _, d := range norm.NFD.String(string(r)) {
+		if unicode.Is(unicode.Mn, d) {
+			if f.lastS && (d == '\u0301' || d == '\u0323') {
+				f.write("h", pos)
+			}
+			continue
+		}
+		d = unicode.ToLower(d)
+		f.write(string(d), pos)
+		f.lastS = d == 's'
+	}
+}
+
+// transliterate writes the Latin form of one Devanagari or Tamil rune.
+// Consonants carry an inherent "a" that is only written once it is clear
+// no vowel sign or virama follows.
+func (f *folder) transliterate(r, next rune, pos int) {
+	if consonant, ok := consonants[r]; ok {
+		f.flush()
+		f.write(consonant, pos)
+		f.pendingVowel = true
+		f.pendingPos = pos
+		return
+	}
+	if sign, ok := vowelSigns[r]; ok {
+		// A vowel sign replaces the inherent vowel of the consonant
+		f.pendingVowel = false
+		f.write(sign, pos)
+		return
+	}
+	if viramas[r] {
+		f.pendingVowel = false
+		return
+	}
+	if r == '\u093C' {
+		// The nukta only modifies the preceding consonant
+		return
+	}
+
+	f.flush()
+	if r == '\u0902' {
+		// The anusvara is written as the nasal of the consonant it precedes
+		f.write(anusvara(next), pos)
+		return
+	}
+	if latin, ok := independents[r]; ok {
+		f.write(latin, pos)
+		return
+	}
+	f.write(string(r), pos)
+}
+
+func anusvara(next rune) string {
+	if consonant, ok := consonants[next]; ok && strings.ContainsAny(consonant[:1], "kgcjtdn") {
+		return "n"
+	}
+	return "m"
+}
+
+func isIndic(r rune) bool {
+	return (r >= 0x0900 && r <= 0x097F) || (r >= 0x0B80 && r <= 0x0BFF)
+}
+
+var viramas = map[rune]bool{
+	'्': true, // Devanagari virama
+	'்': true, // Tamil pulli
+}
+
+var consonants = map[rune]string{
+	// Devanagari
+	'क': "k", 'ख': "kh", 'ग': "g", 'घ': "gh", 'ङ': "n",
+	'च': "c", 'छ': "ch", 'ज': "j", 'झ': "jh", 'ञ': "n",
+	'ट': "t", 'ठ': "th", 'ड': "d", 'ढ': "dh", 'ण': "n",
+	'त': "t", 'थ': "th", 'द': "d", 'ध': "dh", 'न': "n",
+	'प': "p", 'फ': "ph", 'ब': "b", 'भ': "bh", 'म': "m",
+	'य': "y", 'र': "r", 'ल': "l", 'व': "v", 'ळ': "l",
+	'श': "sh", 'ष': "sh", 'स': "s", 'ह': "h",
+
+	// Tamil
+	'க': "k", 'ங': "ng", 'ச': "s", 'ஞ': "ny", 'ட': "t",
+	'ண': "n", 'த': "t", 'ந': "n", 'ப': "p", 'ம': "m",
+	'ய': "y", 'ர': "r", 'ல': "l", 'வ': "v", 'ழ': "zh",
+	'ள': "l", 'ற': "r", 'ன': "n", 'ஜ': "j", 'ஷ': "sh",
+	'ஸ': "s", 'ஹ': "h",
+}
+
+var vowelSigns = map[rune]string{
+	// Devanagari matras
+	'ा': "a", 'ि': "i", 'ी': "i", 'ु': "u", 'ू': "u",
+	'ृ': "r", 'ॄ': "r", 'ॢ': "l", 'े': "e", 'ै': "ai",
+	'ो': "o", 'ौ': "au",
+
+	// Tamil vowel signs
+	'ா': "a", 'ி': "i", 'ீ': "i", 'ு': "u", 'ூ': "u",
+	'ெ': "e", 'ே': "e", 'ை': "ai", 'ொ': "o", 'ோ': "o",
+	'ௌ': "au",
+}
+
+var independents = map[rune]string{
+	// Devanagari vowels and signs
+	'अ': "a", 'आ': "a", 'इ': "i", 'ई': "i", 'उ': "u", 'ऊ': "u",
+	'ऋ': "r", 'ॠ': "r", 'ऌ': "l", 'ए': "e", 'ऐ': "ai", 'ओ': "o",
+	'औ': "au", 'ँ': "m", 'ः': "h", 'ऽ': "",
+	'।': " ", '॥': " ",
+	'०': "0", '१': "1", '२': "2", '३': "3", '४': "4",
+	'५': "5", '६': "6", '७': "7", '८': "8", '९': "9",
+
+	// Tamil vowels and aytham
+	'அ': "a", 'ஆ': "a", 'இ': "i", 'ஈ': "i", 'உ': "u", 'ஊ': "u",
+	'எ': "e", 'ஏ': "e", 'ஐ': "ai", 'ஒ': "o", 'ஓ': "o", 'ஔ': "au",
+	'ஃ': "h",
+}
@@ -0,0 +1,97 @@
+package textnorm
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFold(t *testing.T) {
+	tests := []struct {
+		input, want string
+	}{
+		{"", ""},
+		{"Fever", "fever"},
+		{"vāta", "vata"},
+		{"Vāta", "vata"},
+		{"doṣa", "dosha"},
+		{"śvāsa", "shvasa"},
+		{"Jvara, Kāsa", "jvara, kasa"},
+		{"वात", "vata"},
+		{"ज्वर", "jvara"},
+		{"कास", "kasa"},
+		{"शिर", "shira"},
+		{"वात दोष", "vata dosha"},
+	}
+
+	for _, tt := range tests {
+		if got := Fold(tt.input); got != tt.want {
+			t.Errorf("Fold(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestFoldMap(t *testing.T) {
+	tests := []struct {
+		input   string
+		folded  string
+		offsets []int
+	}{
+		{"", "", []int{0}},
+		{"Ab", "ab", []int{0, 1, 2}},
+		// ā is two bytes and folds to one
+		{"Vāta", "vata", []int{0, 1, 3, 4, 5}},
+		// ś is two bytes and folds to two, both from the same source rune
+		{"śira", "shira", []int{0, 0, 2, 3, 4, 5}},
+		// The inherent "a" of त maps back to the consonant
+		{"वात", "vata", []int{0, 3, 6, 6, 9}},
+		// The virama writes nothing
+		{"क्", "k", []int{0, 6}},
+		{"a वा", "a va", []int{0, 1, 2, 5, 8}},
+	}
+
+	for _, tt := range tests {
+		folded, offsets := FoldMap(tt.input)
+		if folded != tt.folded || !reflect.DeepEqual(offsets, tt.offsets) {
+			t.Errorf("FoldMap(%q) = %q %v, want %q %v", tt.input, folded, offsets, tt.folded, tt.offsets)
+		}
+	}
+}
+
+func TestFoldMapOffsets(t *testing.T) {
+	// A folded span [i, j) maps back to input[offsets[i]:offsets[j]]
+	tests := []struct {
+		input, span, want string
+	}{
+		{"Vāta dosha", "vata", "Vāta"},
+		{"Kapha Doṣa", "dosha", "Doṣa"},
+		{"Aggravated śvāsa", "shvasa", "śvāsa"},
+		{"ज्वर with cough", "jvara", "ज्वर"},
+		{"ज्वर with cough", "cough", "cough"},
+		{"प्रमेह", "prameha", "प्रमेह"},
+	}
+
+	for _, tt := range tests {
+		folded, offsets := FoldMap(tt.input)
+		if len(offsets) != len(folded)+1 || offsets[len(folded)] != len(tt.input) {
+			t.Errorf("FoldMap(%q) gave %d offsets ending in %d for %d folded bytes, want %d ending in %d",
+				tt.input, len(offsets), offsets[len(offsets)-1], len(folded), len(folded)+1, len(tt.input))
+			continue
+		}
+		for i := 1; i < len(offsets); i++ {
+			if offsets[i] < offsets[i-1] {
+				t.Errorf("FoldMap(%q) offsets %v decrease at %d", tt.input, offsets, i)
+				break
+			}
+		}
+
+		i := strings.Index(folded, tt.span)
+		if i < 0 {
+			t.Errorf("Fold(%q) = %q, which does not contain %q", tt.input, folded, tt.span)
+			continue
+		}
+		if got := tt.input[offsets[i]:offsets[i+len(tt.span)]]; got != tt.want {
+			t.Errorf("span %q of %q maps back to %q, want %q", tt.span, tt.input, got, tt.want)
+		}
+	}
+}
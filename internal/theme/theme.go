@@ -0,0 +1,254 @@
+// Package theme defines the colour palettes of the TUI. Themes are either
+// built in or read from YAML files in ~/.medCli/themes, where a custom
+// theme names a built-in base and overrides any of its colours:
+//
+//	base: dark
+//	colors:
+//	  primary: "#268BD2"
+//	  accent: "#2AA198"
+//	ansi:
+//	  primary: 4
+//
+// Colours are hex strings. The optional ansi section gives a 16-colour
+// index (0-15) for terminals without 256-colour support; colours without
+// one are approximated.
+package theme
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"gopkg.in/yaml.v3"
+)
+
+// Default is the theme used when none is configured
+const Default = "dark"
+
+// Palette holds the colour of every role in the interface
+type Palette struct {
+	Primary    string `yaml:"primary"`    // titles, focused borders, logo
+	Secondary  string `yaml:"secondary"`  // subtitles and section headings
+	Accent     string `yaml:"accent"`     // the selected result, matched terms
+	Background string `yaml:"background"` // text drawn on accent
+	Surface    string `yaml:"surface"`    // boxes and inputs
+	Selection  string `yaml:"selection"`  // the selected menu item
+	Border     string `yaml:"border"`
+	Text       string `yaml:"text"`
+	Muted      string `yaml:"muted"` // hints and secondary details
+	Error      string `yaml:"error"` // errors and warnings
+}
+
+// Theme is a named palette with its 16-colour fallbacks
+type Theme struct {
+	Name   string
+	Colors Palette
+	ANSI   map[string]int // role (yaml name) -> 16-colour index
+}
+
+// file is the YAML form of a custom theme
+type file struct {
+	Base   string         `yaml:"base"`
+	Colors Palette        `yaml:"colors"`
+	ANSI   map[string]int `yaml:"ansi"`
+}
+
+var builtins = map[string]Theme{
+	"dark": {
+		Name: "dark",
+		Colors: Palette{
+			Primary:    "#37AC88",
+			Secondary:  "#2D9C78",
+			Accent:     "#45C9A3",
+			Background: "#0A0F0D",
+			Surface:    "#151A17",
+			Selection:  "#1A2A24",
+			Border:     "#2A3A34",
+			Text:       "#E8F3EF",
+			Muted:      "#8A9E96",
+			Error:      "#FF6B6B",
+		},
+		ANSI: map[string]int{"primary": 2, "secondary": 2, "accent": 10, "background": 0, "surface": 0, "selection": 0, "border": 8, "text": 15, "muted": 7, "error": 9},
+	},
+	"light": {
+		Name: "light",
+		Colors: Palette{
+			Primary:    "#1E7A5E",
+			Secondary:  "#2D6A56",
+			Accent:     "#0B8F69",
+			Background: "#FFFFFF",
+			Surface:    "#F4F8F6",
+			Selection:  "#DCEFE7",
+			Border:     "#B7CCC3",
+			Text:       "#1B2420",
+			Muted:      "#5C6E66",
+			Error:      "#C62828",
+		},
+		ANSI: map[string]int{"primary": 2, "secondary": 2, "accent": 2, "background": 15, "surface": 15, "selection": 7, "border": 8, "text": 0, "muted": 8, "error": 1},
+	},
+	"high-contrast": {
+		Name: "high-contrast",
+		Colors: Palette{
+			Primary:    "#FFFF00",
+			Secondary:  "#00FFFF",
+			Accent:     "#FFFFFF",
+			Background: "#000000",
+			Surface:    "#000000",
+			Selection:  "#0000AA",
+			Border:     "#FFFFFF",
+			Text:       "#FFFFFF",
+			Muted:      "#D0D0D0",
+			Error:      "#FF5555",
+		},
+		ANSI: map[string]int{"primary": 11, "secondary": 14, "accent": 15, "background": 0, "surface": 0, "selection": 4, "border": 15, "text": 15, "muted": 7, "error": 9},
+	},
+}
+
+// Dir is where custom themes are read from
+func Dir() string {
+	return filepath.Join(os.ExpandEnv("$HOME"), ".medCli", "themes")
+}
+
+// Names lists the built-in themes followed by the custom themes in dir
+func Names(dir string) []string {
+	var names []string
+	for name := range builtins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var custom []string
+	for _, pattern := range []string{"*.yaml", "*.yml"} {
+		matches, _ := filepath.Glob(filepath.Join(dir, pattern))
+		for _, path := range matches {
+			name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+			if _, ok := builtins[name]; !ok {
+				custom = append(custom, name)
+			}
+		}
+	}
+	sort.Strings(custom)
+	return append(names, custom...)
+}
+
+// ErrUnknown is returned by Load for a theme that is neither built in nor
+// a file in the themes directory
+var ErrUnknown = errors.New("unknown theme")
+
+// Load returns the theme called name: a built-in, or name.yaml or
+// name.yml in dir
+func Load(name, dir string) (*Theme, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		name = Default
+	}
+	if t, ok := builtins[name]; ok {
+		return t.copy(), nil
+	}
+
+	for _, ext := range []string{".yaml", ".yml"} {
+		path := filepath.Join(dir, name+ext)
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read theme %s: %w", path, err)
+		}
+		t, err := parse(name, data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid theme %s: %w", path, err)
+		}
+		return t, nil
+	}
+
+	return nil, fmt.Errorf("%w %q (available: %s)", ErrUnknown, name, strings.Join(Names(dir), ", "))
+}
+
+var hexColor = regexp.MustCompile(`^#([0-9A-Fa-f]{3}|[0-9A-Fa-f]{6})$`)
+
+// parse reads a custom theme and fills the colours it leaves out from its base
+func parse(name string, data []byte) (*Theme, error) {
+	var f file
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+
+	if f.Base == "" {
+		f.Base = Default
+	}
+	base, ok := builtins[strings.ToLower(f.Base)]
+	if !ok {
+		return nil, fmt.Errorf("base must be a built-in theme, not %q", f.Base)
+	}
+
+	t := base.copy()
+	t.Name = name
+	overrides := f.Colors.roles()
+	for role, color := range t.Colors.roles() {
+		value := *overrides[role]
+		if value == "" {
+			continue
+		}
+		if !hexColor.MatchString(value) {
+			return nil, fmt.Errorf("%s: %q is not a hex colour such as #37AC88", role, value)
+		}
+		*color = value
+		// A new colour invalidates the base's fallback unless one is given
+		delete(t.ANSI, role)
+	}
+	for role, index := range f.ANSI {
+		if _, ok := overrides[role]; !ok {
+			return nil, fmt.Errorf("ansi: unknown colour role %q", role)
+		}
+		if index < 0 || index > 15 {
+			return nil, fmt.Errorf("ansi: %s must be between 0 and 15", role)
+		}
+		t.ANSI[role] = index
+	}
+
+	return t, nil
+}
+
+// roles maps each yaml role name to its field
+func (p *Palette) roles() map[string]*string {
+	return map[string]*string{
+		"primary":    &p.Primary,
+		"secondary":  &p.Secondary,
+		"accent":     &p.Accent,
+		"background": &p.Background,
+		"surface":    &p.Surface,
+		"selection":  &p.Selection,
+		"border":     &p.Border,
+		"text":       &p.Text,
+		"muted":      &p.Muted,
+		"error":      &p.Error,
+	}
+}
+
+func (t Theme) copy() *Theme {
+	ansi := make(map[string]int, len(t.ANSI))
+	for role, index := range t.ANSI {
+		ansi[role] = index
+	}
+	t.ANSI = ansi
+	return &t
+}
+
+// Color returns the colour of role, a yaml role name such as "primary".
+// Terminals limited to 16 colours get the theme's fallback index when it
+// has one, and every other terminal an approximation of the hex colour.
+func (t *Theme) Color(role string) lipgloss.TerminalColor {
+	hex := *t.Colors.roles()[role]
+	ansi := hex
+	if index, ok := t.ANSI[role]; ok {
+		ansi = strconv.Itoa(index)
+	}
+	return lipgloss.CompleteColor{TrueColor: hex, ANSI256: hex, ANSI: ansi}
+}